@@ -0,0 +1,237 @@
+package baseline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/output"
+)
+
+// FormatVersion is the version of the baseline file format written by Writer.
+const FormatVersion = 1
+
+// File is the on-disk representation of a baseline.
+type File struct {
+	Version     int       `json:"version"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Findings    []Entry   `json:"findings"`
+}
+
+// Entry is a single accepted finding. A result matches an entry if its
+// fingerprint is equal, or if its source, repository, detector, secret hash
+// and path are all equal. The latter keeps a finding suppressed when
+// unrelated edits move it to another line or commit, without suppressing the
+// same secret found in another repository or by another detector.
+type Entry struct {
+	Fingerprint  string `json:"fingerprint,omitempty"`
+	SecretHash   string `json:"secret_hash,omitempty"`
+	Source       string `json:"source,omitempty"`
+	Repository   string `json:"repository,omitempty"`
+	Path         string `json:"path,omitempty"`
+	DetectorType string `json:"detector_type,omitempty"`
+	// Reason is free text for reviewers and is not used for matching.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Baseline is a set of previously accepted findings.
+type Baseline struct {
+	fingerprints map[string]struct{}
+	secretPaths  map[string]struct{}
+}
+
+// Load reads a baseline file.
+func Load(filename string) (*Baseline, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read baseline: %w", err)
+	}
+
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("unable to parse baseline %s: %w", filename, err)
+	}
+	if f.Version > FormatVersion {
+		return nil, fmt.Errorf("baseline %s has unsupported version %d", filename, f.Version)
+	}
+	return New(f.Findings), nil
+}
+
+// New creates a Baseline from a list of entries.
+func New(entries []Entry) *Baseline {
+	b := &Baseline{
+		fingerprints: make(map[string]struct{}),
+		secretPaths:  make(map[string]struct{}),
+	}
+	for _, e := range entries {
+		if e.Fingerprint != "" {
+			b.fingerprints[e.Fingerprint] = struct{}{}
+		}
+		if e.SecretHash != "" {
+			b.secretPaths[secretPathKey(e)] = struct{}{}
+		}
+	}
+	return b
+}
+
+// Len returns the number of accepted findings.
+func (b *Baseline) Len() int { return len(b.fingerprints) + len(b.secretPaths) }
+
+// Contains reports whether the result was previously accepted.
+func (b *Baseline) Contains(r *detectors.ResultWithMetadata) (bool, error) {
+	e, err := entryFor(r)
+	if err != nil {
+		return false, err
+	}
+	if _, ok := b.fingerprints[e.Fingerprint]; ok {
+		return true, nil
+	}
+	_, ok := b.secretPaths[secretPathKey(e)]
+	return ok, nil
+}
+
+// Filter is a printer that drops results contained in a baseline and passes
+// everything else to the next printer.
+type Filter struct {
+	baseline   *Baseline
+	next       output.Printer
+	suppressed atomic.Uint64
+}
+
+// NewFilter creates a Filter in front of next.
+func NewFilter(b *Baseline, next output.Printer) *Filter {
+	return &Filter{baseline: b, next: next}
+}
+
+func (f *Filter) Print(ctx context.Context, r *detectors.ResultWithMetadata) error {
+	known, err := f.baseline.Contains(r)
+	if err != nil {
+		return err
+	}
+	if known {
+		f.suppressed.Add(1)
+		ctx.Logger().V(3).Info("suppressed result found in baseline", "detector", r.DetectorType.String())
+		return nil
+	}
+	return f.next.Print(ctx, r)
+}
+
+// Suppressed returns the number of results dropped so far.
+func (f *Filter) Suppressed() uint64 { return f.suppressed.Load() }
+
+// Writer is a printer that collects results and writes them as a new
+// baseline file when closed.
+type Writer struct {
+	mu       sync.Mutex
+	filename string
+	seen     map[string]struct{}
+	entries  []Entry
+}
+
+// NewWriter creates a Writer that writes the baseline to filename.
+func NewWriter(filename string) *Writer {
+	return &Writer{filename: filename, seen: make(map[string]struct{})}
+}
+
+func (w *Writer) Print(_ context.Context, r *detectors.ResultWithMetadata) error {
+	e, err := entryFor(r)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, ok := w.seen[e.Fingerprint]; ok {
+		return nil
+	}
+	w.seen[e.Fingerprint] = struct{}{}
+	w.entries = append(w.entries, e)
+	return nil
+}
+
+// Close writes the collected results to the baseline file. The file is
+// replaced atomically so an interrupted run never leaves a truncated
+// baseline behind.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	entries := w.entries
+	if entries == nil {
+		entries = []Entry{}
+	}
+	// Sort for stable diffs when the baseline is checked in.
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Path != entries[j].Path {
+			return entries[i].Path < entries[j].Path
+		}
+		return entries[i].Fingerprint < entries[j].Fingerprint
+	})
+
+	data, err := json.MarshalIndent(File{
+		Version:     FormatVersion,
+		GeneratedAt: time.Now().UTC(),
+		Findings:    entries,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode baseline: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(w.filename), ".baseline-*")
+	if err != nil {
+		return fmt.Errorf("unable to write baseline: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("unable to write baseline: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("unable to write baseline: %w", err)
+	}
+	// CreateTemp creates the file readable by its owner only, but the
+	// baseline is meant to be checked in and shared.
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("unable to write baseline: %w", err)
+	}
+	return os.Rename(tmp.Name(), w.filename)
+}
+
+func entryFor(r *detectors.ResultWithMetadata) (Entry, error) {
+	loc, err := output.LocationOf(r)
+	if err != nil {
+		return Entry{}, fmt.Errorf("unable to read result metadata: %w", err)
+	}
+	fingerprint, err := output.Fingerprint(r)
+	if err != nil {
+		return Entry{}, fmt.Errorf("unable to fingerprint result: %w", err)
+	}
+	return Entry{
+		Fingerprint:  fingerprint,
+		SecretHash:   output.SecretHash(r),
+		Source:       r.SourceType.String(),
+		Repository:   output.NormalizeRepository(loc.Repository),
+		Path:         output.NormalizePath(loc.File),
+		DetectorType: r.DetectorType.String(),
+	}, nil
+}
+
+func secretPathKey(e Entry) string {
+	return strings.Join([]string{
+		e.Source,
+		output.NormalizeRepository(e.Repository),
+		strings.ToLower(e.DetectorType),
+		strings.ToLower(e.SecretHash),
+		output.NormalizePath(e.Path),
+	}, "\x00")
+}
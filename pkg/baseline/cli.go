@@ -0,0 +1,40 @@
+package baseline
+
+import (
+	"github.com/alecthomas/kingpin/v2"
+)
+
+var (
+	baselineFile *string
+	writeFile    *string
+)
+
+// Flags registers the baseline flags on app.
+func Flags(app *kingpin.Application) {
+	baselineFile = app.Flag("baseline", "Drop results that were accepted in this baseline file.").String()
+	writeFile = app.Flag("write-baseline", "Write the results of the scan to this file as a new baseline.").String()
+}
+
+// FromFlags returns the baseline given by --baseline and a Writer for the
+// file given by --write-baseline. Either is nil if its flag is not set.
+//
+// The baseline's Filter goes in front of the printer. The Writer must receive
+// every result, including those the baseline drops, so that a rewritten
+// baseline still contains the findings accepted before; it is closed after
+// the scan to write the file.
+func FromFlags() (*Baseline, *Writer, error) {
+	var (
+		b   *Baseline
+		w   *Writer
+		err error
+	)
+	if *baselineFile != "" {
+		if b, err = Load(*baselineFile); err != nil {
+			return nil, nil, err
+		}
+	}
+	if *writeFile != "" {
+		w = NewWriter(*writeFile)
+	}
+	return b, w, nil
+}
@@ -0,0 +1,240 @@
+// Package budget bounds how long the engine may spend on a single detector
+// call, a single source unit and the scan as a whole. Work is given a context
+// whose deadline is its budget; work that runs over it is cancelled and the
+// timeout recorded, so one pathological input cannot hang a scan without
+// anyone noticing.
+package budget
+
+import (
+	stdcontext "context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/summary"
+)
+
+// ErrTimeout is wrapped by the errors returned for work that exceeded its
+// budget.
+var ErrTimeout = errors.New("time budget exceeded")
+
+// Limits are the time budgets. A zero value disables the respective limit.
+type Limits struct {
+	// Detector is the maximum time a single detector may spend on a chunk.
+	Detector time.Duration
+	// Unit is the maximum time spent chunking a single source unit.
+	Unit time.Duration
+	// Scan is the maximum time of the whole scan.
+	Scan time.Duration
+}
+
+// Recorder receives the work that timed out. summary.Collector implements it.
+type Recorder interface {
+	RecordTimeout(summary.Timeout)
+}
+
+// Budget enforces Limits and reports timeouts to a Recorder.
+type Budget struct {
+	limits   Limits
+	recorder Recorder
+	locate   func(*sources.Chunk) string
+	// overruns counts the timed out runs that kept going for more than their
+	// limit again after their deadline, because they ignore cancellation.
+	overruns atomic.Uint64
+}
+
+// New creates a Budget. recorder may be nil, in which case timeouts are only
+// returned as errors and logged.
+func New(limits Limits, recorder Recorder) *Budget {
+	return &Budget{limits: limits, recorder: recorder}
+}
+
+// WithLocator sets how the location of a chunk whose detector call timed out
+// is described in the recorded timeout, such as output.ChunkLocation.
+// Without it, detector timeouts have no location.
+func (b *Budget) WithLocator(locate func(*sources.Chunk) string) {
+	b.locate = locate
+}
+
+// Limits returns the limits the Budget enforces.
+func (b *Budget) Limits() Limits { return b.limits }
+
+// Overruns returns the number of timed out detector calls and units that
+// ignored their cancelled context and kept running for more than their limit
+// past their deadline. Each one held a worker for that long.
+func (b *Budget) Overruns() uint64 { return b.overruns.Load() }
+
+// checkOverrun counts and logs a run that took more than twice its limit.
+func (b *Budget) checkOverrun(ctx context.Context, t summary.Timeout, elapsed time.Duration) {
+	overrun := elapsed - time.Duration(t.Limit)
+	if overrun <= time.Duration(t.Limit) {
+		return
+	}
+	b.overruns.Add(1)
+	ctx.Logger().Info("work ignored its cancellation and overran its time budget",
+		"kind", t.Kind,
+		"limit", time.Duration(t.Limit).String(),
+		"overrun", overrun.String(),
+		"source", t.Source,
+		"unit", t.Unit,
+		"detector", t.Detector,
+	)
+}
+
+func (b *Budget) record(ctx context.Context, t summary.Timeout) {
+	ctx.Logger().Info("time budget exceeded, abandoning work",
+		"kind", t.Kind,
+		"limit", time.Duration(t.Limit).String(),
+		"source", t.Source,
+		"unit", t.Unit,
+		"detector", t.Detector,
+		"location", t.Location,
+	)
+	if b.recorder != nil {
+		b.recorder.RecordTimeout(t)
+	}
+}
+
+// FromData runs d.FromData on data, which was taken from chunk, with a
+// context whose deadline is the detector limit. If the deadline passes before
+// the detector returns, the timeout is recorded. The results the detector
+// returned anyway are kept, because they were found before the deadline and
+// only their verification may have been cut short; those that are not
+// verified get a verification error wrapping ErrTimeout if verify is set.
+// Without results, an error wrapping ErrTimeout is returned. The detector
+// runs on the calling goroutine, so one that ignores its context delays the
+// caller rather than running on in the background; such overruns are counted
+// by Overruns.
+func (b *Budget) FromData(ctx context.Context, d detectors.Detector, verify bool, chunk *sources.Chunk, data []byte) ([]detectors.Result, error) {
+	if b.limits.Detector <= 0 {
+		return d.FromData(ctx, verify, data)
+	}
+
+	start := time.Now()
+	detectorCtx, cancel := context.WithTimeout(ctx, b.limits.Detector)
+	defer cancel()
+	results, err := d.FromData(detectorCtx, verify, data)
+	if !errors.Is(detectorCtx.Err(), stdcontext.DeadlineExceeded) {
+		return results, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	t := summary.Timeout{
+		Kind:     summary.TimeoutDetector,
+		Detector: d.Type().String(),
+		Limit:    summary.Duration(b.limits.Detector),
+	}
+	if chunk != nil {
+		t.Source = chunk.SourceName
+		if b.locate != nil {
+			t.Location = b.locate(chunk)
+		}
+	}
+	b.record(ctx, t)
+	b.checkOverrun(ctx, t, time.Since(start))
+
+	timeoutErr := fmt.Errorf("detector %s: %w", t.Detector, ErrTimeout)
+	if len(results) == 0 {
+		return nil, timeoutErr
+	}
+	if verify {
+		for i := range results {
+			if !results[i].Verified && results[i].VerificationError() == nil {
+				results[i].SetVerificationError(timeoutErr)
+			}
+		}
+	}
+	return results, nil
+}
+
+// ChunkUnit chunks unit with src, which is named sourceName, with a context
+// whose deadline is the unit limit. Once the deadline passes, chunks that are
+// still reported for the unit are dropped and the reporter returns
+// ErrTimeout, so the source stops. The timeout is then recorded and an error
+// wrapping ErrTimeout is returned, so the source can move on to its next
+// unit.
+func (b *Budget) ChunkUnit(ctx context.Context, src sources.SourceUnitChunker, sourceName string, unit sources.SourceUnit, reporter sources.ChunkReporter) error {
+	if b.limits.Unit <= 0 {
+		return src.ChunkUnit(ctx, unit, reporter)
+	}
+
+	start := time.Now()
+	unitCtx, cancel := context.WithTimeout(ctx, b.limits.Unit)
+	defer cancel()
+	gated := &gatedReporter{reporter: reporter}
+	stop := stdcontext.AfterFunc(unitCtx, gated.close)
+	defer stop()
+
+	err := src.ChunkUnit(unitCtx, unit, gated)
+	if !errors.Is(unitCtx.Err(), stdcontext.DeadlineExceeded) {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	t := summary.Timeout{
+		Kind:   summary.TimeoutUnit,
+		Source: sourceName,
+		Unit:   unit.Display(),
+		Limit:  summary.Duration(b.limits.Unit),
+	}
+	b.record(ctx, t)
+	b.checkOverrun(ctx, t, time.Since(start))
+	return fmt.Errorf("unit %s: %w", unit.Display(), ErrTimeout)
+}
+
+// WithScanDeadline returns a context that is cancelled when the scan limit is
+// reached, recording the timeout. The returned stop function releases the
+// resources of the deadline and must be called once the scan is over; a scan
+// that finished in time is not recorded.
+func (b *Budget) WithScanDeadline(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	if b.limits.Scan <= 0 {
+		return ctx, cancel
+	}
+
+	timer := time.AfterFunc(b.limits.Scan, func() {
+		if ctx.Err() != nil {
+			return
+		}
+		b.record(ctx, summary.Timeout{
+			Kind:  summary.TimeoutScan,
+			Limit: summary.Duration(b.limits.Scan),
+		})
+		cancel()
+	})
+	return ctx, func() {
+		timer.Stop()
+		cancel()
+	}
+}
+
+// gatedReporter forwards to reporter until it is closed and drops everything
+// reported afterwards by a unit that ignored its cancellation.
+type gatedReporter struct {
+	reporter sources.ChunkReporter
+	closed   atomic.Bool
+}
+
+func (g *gatedReporter) close() { g.closed.Store(true) }
+
+func (g *gatedReporter) ChunkOk(ctx context.Context, chunk sources.Chunk) error {
+	if g.closed.Load() {
+		return ErrTimeout
+	}
+	return g.reporter.ChunkOk(ctx, chunk)
+}
+
+func (g *gatedReporter) ChunkErr(ctx context.Context, err error) error {
+	if g.closed.Load() {
+		return ErrTimeout
+	}
+	return g.reporter.ChunkErr(ctx, err)
+}
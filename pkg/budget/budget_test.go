@@ -0,0 +1,191 @@
+package budget
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	logContext "github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/summary"
+)
+
+type recorder struct {
+	mu       sync.Mutex
+	timeouts []summary.Timeout
+}
+
+func (r *recorder) RecordTimeout(t summary.Timeout) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.timeouts = append(r.timeouts, t)
+}
+
+func (r *recorder) recorded() []summary.Timeout {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]summary.Timeout(nil), r.timeouts...)
+}
+
+// slowDetector blocks until its context is cancelled, or for ignoreCtx if
+// set. If verifies is set, it finds a result first and blocks verifying it.
+type slowDetector struct {
+	ignoreCtx time.Duration
+	verifies  bool
+}
+
+func (d slowDetector) FromData(ctx context.Context, _ bool, data []byte) ([]detectors.Result, error) {
+	if string(data) == "fast" {
+		return []detectors.Result{{Raw: data}}, nil
+	}
+	if d.ignoreCtx > 0 {
+		time.Sleep(d.ignoreCtx)
+		return nil, nil
+	}
+	<-ctx.Done()
+	if d.verifies {
+		return []detectors.Result{{Raw: data}}, nil
+	}
+	return nil, ctx.Err()
+}
+
+func (slowDetector) Keywords() []string             { return nil }
+func (slowDetector) Type() detectorspb.DetectorType { return detectorspb.DetectorType_AWS }
+func (slowDetector) Description() string            { return "" }
+
+func TestFromData(t *testing.T) {
+	ctx := logContext.Background()
+	rec := &recorder{}
+	b := New(Limits{Detector: 20 * time.Millisecond}, rec)
+	b.WithLocator(func(c *sources.Chunk) string { return c.SourceMetadata.GetFilesystem().GetFile() })
+	chunk := &sources.Chunk{
+		SourceName: "fs",
+		SourceMetadata: &source_metadatapb.MetaData{
+			Data: &source_metadatapb.MetaData_Filesystem{
+				Filesystem: &source_metadatapb.Filesystem{File: "huge.min.js"},
+			},
+		},
+	}
+
+	results, err := b.FromData(ctx, slowDetector{}, false, chunk, []byte("fast"))
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Empty(t, rec.recorded())
+
+	for _, d := range []slowDetector{{}, {ignoreCtx: 100 * time.Millisecond}} {
+		results, err = b.FromData(ctx, d, false, chunk, []byte("slow"))
+		assert.ErrorIs(t, err, ErrTimeout)
+		assert.Empty(t, results)
+	}
+	assert.Equal(t, uint64(1), b.Overruns())
+
+	// Results found before the deadline are kept, but not trusted as
+	// verified.
+	results, err = b.FromData(ctx, slowDetector{verifies: true}, true, chunk, []byte("slow"))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.False(t, results[0].Verified)
+	assert.ErrorIs(t, results[0].VerificationError(), ErrTimeout)
+
+	timeouts := rec.recorded()
+	require.Len(t, timeouts, 3)
+	assert.Equal(t, summary.Timeout{
+		Kind:     summary.TimeoutDetector,
+		Source:   "fs",
+		Detector: "AWS",
+		Location: "huge.min.js",
+		Limit:    summary.Duration(20 * time.Millisecond),
+	}, timeouts[0])
+
+	cancelled, cancel := logContext.WithCancel(ctx)
+	cancel()
+	_, err = b.FromData(cancelled, slowDetector{}, false, chunk, []byte("slow"))
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrTimeout)
+	assert.Len(t, rec.recorded(), 3)
+}
+
+type unitChunker struct{}
+
+func (unitChunker) ChunkUnit(ctx logContext.Context, unit sources.SourceUnit, reporter sources.ChunkReporter) error {
+	for i := 0; ; i++ {
+		if err := reporter.ChunkOk(ctx, sources.Chunk{}); err != nil {
+			return err
+		}
+		if unit.Display() == "small" && i == 2 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+type countingReporter struct {
+	mu     sync.Mutex
+	chunks int
+}
+
+func (c *countingReporter) ChunkOk(logContext.Context, sources.Chunk) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.chunks++
+	return nil
+}
+
+func (c *countingReporter) ChunkErr(logContext.Context, error) error { return nil }
+
+func TestChunkUnit(t *testing.T) {
+	ctx := logContext.Background()
+	rec := &recorder{}
+	b := New(Limits{Unit: 20 * time.Millisecond}, rec)
+
+	reporter := &countingReporter{}
+	require.NoError(t, b.ChunkUnit(ctx, unitChunker{}, "git", sources.CommonSourceUnit{ID: "small"}, reporter))
+	assert.Equal(t, 3, reporter.chunks)
+
+	err := b.ChunkUnit(ctx, unitChunker{}, "git", sources.CommonSourceUnit{ID: "huge"}, reporter)
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.Equal(t, []summary.Timeout{{
+		Kind:   summary.TimeoutUnit,
+		Source: "git",
+		Unit:   "huge",
+		Limit:  summary.Duration(20 * time.Millisecond),
+	}}, rec.recorded())
+}
+
+func TestWithScanDeadline(t *testing.T) {
+	rec := &recorder{}
+	b := New(Limits{Scan: 10 * time.Millisecond}, rec)
+
+	ctx, stop := b.WithScanDeadline(logContext.Background())
+	defer stop()
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("scan deadline was not enforced")
+	}
+	assert.Equal(t, []summary.Timeout{{Kind: summary.TimeoutScan, Limit: summary.Duration(10 * time.Millisecond)}}, rec.recorded())
+
+	// A scan that finishes in time is not recorded.
+	rec = &recorder{}
+	b = New(Limits{Scan: time.Hour}, rec)
+	ctx, stop = b.WithScanDeadline(logContext.Background())
+	stop()
+	assert.Error(t, ctx.Err())
+	assert.Empty(t, rec.recorded())
+
+	// Without a limit, the context is only cancelled by stop.
+	ctx, stop = New(Limits{}, nil).WithScanDeadline(logContext.Background())
+	assert.NoError(t, ctx.Err())
+	stop()
+}
@@ -0,0 +1,29 @@
+package budget
+
+import (
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+)
+
+var (
+	detectorTimeout *time.Duration
+	unitTimeout     *time.Duration
+	scanTimeout     *time.Duration
+)
+
+// Flags registers the time budget flags on app.
+func Flags(app *kingpin.Application) {
+	detectorTimeout = app.Flag("detector-timeout", "Maximum time a detector may spend on a single chunk (e.g. 30s). 0 means no limit.").Default("0").Duration()
+	unitTimeout = app.Flag("unit-timeout", "Maximum time spent on a single source unit, such as a repository or bucket. 0 means no limit.").Default("0").Duration()
+	scanTimeout = app.Flag("scan-timeout", "Maximum time of the whole scan. 0 means no limit.").Default("0").Duration()
+}
+
+// LimitsFromFlags returns the limits given on the command line.
+func LimitsFromFlags() Limits {
+	return Limits{
+		Detector: *detectorTimeout,
+		Unit:     *unitTimeout,
+		Scan:     *scanTimeout,
+	}
+}
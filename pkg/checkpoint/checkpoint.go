@@ -0,0 +1,393 @@
+// Package checkpoint records the progress of a scan in a file, so an
+// interrupted scan can be resumed without rescanning what was already
+// covered.
+package checkpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// FormatVersion is the version of the checkpoint file format.
+const FormatVersion = 1
+
+// DefaultInterval is how often the checkpoint file is written while a scan is
+// running.
+const DefaultInterval = 30 * time.Second
+
+// File is the on-disk format of a checkpoint.
+type File struct {
+	Version   int                     `json:"version"`
+	UpdatedAt time.Time               `json:"updated_at"`
+	Sources   map[string]*SourceState `json:"sources"`
+}
+
+// SourceState is the progress of one source, keyed by source name in File.
+type SourceState struct {
+	// EncodedResumeInfo is the source's Progress.EncodedResumeInfo.
+	EncodedResumeInfo string `json:"encoded_resume_info,omitempty"`
+	// CompletedUnits are the source units whose chunks were all scanned.
+	CompletedUnits []string `json:"completed_units,omitempty"`
+	// Done is set once the source finished without errors.
+	Done bool `json:"done,omitempty"`
+}
+
+type sourceState struct {
+	src sources.Source
+
+	// resume, completed and done are committed: they describe work whose
+	// chunks the engine has scanned, and are all that is written to the
+	// checkpoint file.
+	resume    string
+	completed map[string]struct{}
+	done      bool
+
+	// chunked are the units that finished chunking since the last commit.
+	chunked map[string]struct{}
+	// failedUnits are the units that reported an error while chunking. They
+	// are never recorded as completed.
+	failedUnits map[string]struct{}
+	failed      bool
+	finished    bool
+}
+
+// Checkpointer tracks the progress of the sources of a scan and periodically
+// writes it to a checkpoint file. It is a sources.JobProgressHook, to be
+// registered with sources.WithReportHook, so that it learns which units
+// finished chunking.
+//
+// A unit that finished chunking is not complete until the engine has scanned
+// its chunks, so progress is staged and only recorded by Commit, which waits
+// for the engine to drain first.
+type Checkpointer struct {
+	sources.NoopHook
+
+	filename string
+
+	mu      sync.Mutex
+	sources map[string]*sourceState
+	// stopped is set once the scan is interrupted. Nothing is committed
+	// afterwards, because the engine may not have scanned what was staged.
+	stopped bool
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New creates a Checkpointer that writes to filename, starting from scratch.
+func New(filename string) *Checkpointer {
+	return &Checkpointer{filename: filename, sources: make(map[string]*sourceState)}
+}
+
+// Load creates a Checkpointer that continues the scan recorded in filename
+// and keeps writing to it.
+func Load(filename string) (*Checkpointer, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read checkpoint: %w", err)
+	}
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("unable to parse checkpoint %s: %w", filename, err)
+	}
+	if f.Version != FormatVersion {
+		return nil, fmt.Errorf("unsupported checkpoint version %d", f.Version)
+	}
+
+	c := New(filename)
+	for name, st := range f.Sources {
+		if st == nil {
+			continue
+		}
+		state := c.state(name)
+		state.resume = st.EncodedResumeInfo
+		state.done = st.Done
+		for _, unit := range st.CompletedUnits {
+			state.completed[unit] = struct{}{}
+		}
+	}
+	return c, nil
+}
+
+// Track registers src under name, which must be unique within the scan. If
+// the checkpoint holds resume info for name, it is restored into the
+// source's progress, so it must be called after Init and before the source
+// starts chunking. Track returns false if the source already finished in the
+// checkpointed scan and does not need to run again.
+func (c *Checkpointer) Track(name string, src sources.Source) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state := c.state(name)
+	state.src = src
+	if state.done {
+		return false
+	}
+	if state.resume != "" {
+		src.GetProgress().SetResumeInfo(state.resume)
+	}
+	return true
+}
+
+// Completed reports whether unit of source name was fully scanned in the
+// checkpointed scan.
+func (c *Checkpointer) Completed(name string, unit sources.SourceUnit) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	state, ok := c.sources[name]
+	if !ok {
+		return false
+	}
+	_, ok = state.completed[unitKey(unit)]
+	return ok
+}
+
+// SkipCompleted wraps the unit reporter passed to a source's Enumerate so
+// that units completed in the checkpointed scan are not chunked again.
+func (c *Checkpointer) SkipCompleted(name string, reporter sources.UnitReporter) sources.UnitReporter {
+	return &skippingReporter{c: c, name: name, next: reporter}
+}
+
+type skippingReporter struct {
+	c    *Checkpointer
+	name string
+	next sources.UnitReporter
+}
+
+func (r *skippingReporter) UnitOk(ctx context.Context, unit sources.SourceUnit) error {
+	if r.c.Completed(r.name, unit) {
+		ctx.Logger().V(2).Info("skipping unit completed before resume", "unit", unit.Display())
+		return nil
+	}
+	return r.next.UnitOk(ctx, unit)
+}
+
+func (r *skippingReporter) UnitErr(ctx context.Context, err error) error {
+	return r.next.UnitErr(ctx, err)
+}
+
+// EndUnitChunking stages unit for the next Commit. The source manager also
+// ends units whose chunking failed, so units that reported an error are
+// left out.
+func (c *Checkpointer) EndUnitChunking(ref sources.JobProgressRef, unit sources.SourceUnit, _ time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stopped || unit == nil {
+		return
+	}
+	state := c.state(ref.SourceName)
+	key := unitKey(unit)
+	if _, failed := state.failedUnits[key]; failed {
+		return
+	}
+	state.chunked[key] = struct{}{}
+}
+
+// ReportError marks the unit of a sources.ChunkError as failed. Any error
+// keeps the source from being recorded as done.
+func (c *Checkpointer) ReportError(ref sources.JobProgressRef, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	state := c.state(ref.SourceName)
+	state.failed = true
+
+	var chunkErr sources.ChunkError
+	if errors.As(err, &chunkErr) && chunkErr.Unit != nil {
+		key := unitKey(chunkErr.Unit)
+		state.failedUnits[key] = struct{}{}
+		delete(state.chunked, key)
+	}
+}
+
+func (c *Checkpointer) Finish(ref sources.JobProgressRef) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.stopped {
+		c.state(ref.SourceName).finished = true
+	}
+}
+
+// staged is the progress captured by Commit before draining the engine.
+type staged struct {
+	units    map[string][]string
+	resume   map[string]string
+	finished map[string]bool
+}
+
+func (c *Checkpointer) stage() staged {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	st := staged{units: make(map[string][]string), resume: make(map[string]string), finished: make(map[string]bool)}
+	for name, state := range c.sources {
+		for unit := range state.chunked {
+			st.units[name] = append(st.units[name], unit)
+		}
+		if state.src != nil {
+			st.resume[name] = state.src.GetProgress().ResumeInfo()
+		}
+		st.finished[name] = state.finished && !state.failed
+	}
+	return st
+}
+
+// Commit records the progress staged so far as complete and writes the
+// checkpoint. The progress is captured first, then drain is called, which
+// must return once the engine has scanned every chunk reported before the
+// call; only then is the captured progress recorded. drain may be nil if
+// the engine has already finished. Nothing is recorded if the scan was
+// interrupted in the meantime.
+func (c *Checkpointer) Commit(drain func()) error {
+	st := c.stage()
+	if drain != nil {
+		drain()
+	}
+
+	c.mu.Lock()
+	if !c.stopped {
+		for name, state := range c.sources {
+			for _, unit := range st.units[name] {
+				if _, failed := state.failedUnits[unit]; failed {
+					continue
+				}
+				state.completed[unit] = struct{}{}
+				delete(state.chunked, unit)
+			}
+			// Keep the resume info from the checkpoint until the source
+			// reports its own, so a resumed scan that is interrupted again
+			// early does not lose it.
+			if info := st.resume[name]; info != "" {
+				state.resume = info
+			}
+			if st.finished[name] && !state.failed {
+				state.done = true
+			}
+		}
+	}
+	c.mu.Unlock()
+	return c.Save()
+}
+
+// Start commits the checkpoint every interval until Close is called, using
+// drain to wait for the engine as described for Commit. If drain is nil, the
+// periodic writes only repeat what was committed. If ctx is cancelled, the
+// scan is considered interrupted: nothing is committed afterwards, and the
+// checkpoint is written one last time.
+func (c *Checkpointer) Start(ctx context.Context, interval time.Duration, drain func()) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	c.stop = make(chan struct{})
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.stop:
+				return
+			case <-ctx.Done():
+				c.mu.Lock()
+				c.stopped = true
+				c.mu.Unlock()
+				if err := c.Save(); err != nil {
+					ctx.Logger().Error(err, "unable to write checkpoint")
+				}
+				return
+			case <-ticker.C:
+				var err error
+				if drain != nil {
+					err = c.Commit(drain)
+				} else {
+					err = c.Save()
+				}
+				if err != nil {
+					ctx.Logger().Error(err, "unable to write checkpoint")
+				}
+			}
+		}
+	}()
+}
+
+// Close stops the periodic writes and writes the checkpoint a final time.
+// It does not commit; call Commit first once the engine has finished.
+func (c *Checkpointer) Close() error {
+	if c.stop != nil {
+		close(c.stop)
+		c.wg.Wait()
+		c.stop = nil
+	}
+	return c.Save()
+}
+
+// Save writes the current state to the checkpoint file atomically.
+func (c *Checkpointer) Save() error {
+	data, err := json.MarshalIndent(c.snapshot(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode checkpoint: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.filename), ".checkpoint-*")
+	if err != nil {
+		return fmt.Errorf("unable to write checkpoint: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("unable to write checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("unable to write checkpoint: %w", err)
+	}
+	return os.Rename(tmp.Name(), c.filename)
+}
+
+func (c *Checkpointer) snapshot() File {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f := File{Version: FormatVersion, UpdatedAt: time.Now().UTC(), Sources: make(map[string]*SourceState, len(c.sources))}
+	for name, state := range c.sources {
+		st := &SourceState{EncodedResumeInfo: state.resume, Done: state.done}
+		for unit := range state.completed {
+			st.CompletedUnits = append(st.CompletedUnits, unit)
+		}
+		sort.Strings(st.CompletedUnits)
+		f.Sources[name] = st
+	}
+	return f
+}
+
+// state must be called with c.mu held.
+func (c *Checkpointer) state(name string) *sourceState {
+	state, ok := c.sources[name]
+	if !ok {
+		state = &sourceState{
+			completed:   make(map[string]struct{}),
+			chunked:     make(map[string]struct{}),
+			failedUnits: make(map[string]struct{}),
+		}
+		c.sources[name] = state
+	}
+	return state
+}
+
+// unitKey identifies a unit within its source.
+func unitKey(unit sources.SourceUnit) string {
+	id, kind := unit.SourceUnitID()
+	if kind == "" {
+		return id
+	}
+	return string(kind) + ":" + id
+}
@@ -0,0 +1,209 @@
+package checkpoint
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+type fakeSource struct {
+	sources.Progress
+}
+
+func (s *fakeSource) Type() sourcespb.SourceType { return sourcespb.SourceType_SOURCE_TYPE_GIT }
+func (s *fakeSource) SourceID() sources.SourceID { return 0 }
+func (s *fakeSource) JobID() sources.JobID       { return 0 }
+func (s *fakeSource) Init(context.Context, string, sources.JobID, sources.SourceID, bool, *anypb.Any, int) error {
+	return nil
+}
+func (s *fakeSource) Chunks(context.Context, chan *sources.Chunk, ...sources.ChunkingTarget) error {
+	return nil
+}
+
+type unitCollector struct{ units []string }
+
+func (r *unitCollector) UnitOk(_ context.Context, unit sources.SourceUnit) error {
+	r.units = append(r.units, unit.Display())
+	return nil
+}
+func (r *unitCollector) UnitErr(context.Context, error) error { return nil }
+
+func TestCheckpointResume(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	filename := filepath.Join(t.TempDir(), "scan.checkpoint")
+
+	// First run: two sources, one finishes and one is interrupted after
+	// one of its units was scanned and another one was chunked.
+	c := New(filename)
+	done, interrupted := &fakeSource{}, &fakeSource{}
+	assert.True(t, c.Track("done", done))
+	assert.True(t, c.Track("org", interrupted))
+	c.Start(ctx, time.Hour, nil)
+
+	doneRef := sources.JobProgressRef{SourceName: "done"}
+	c.EndUnitChunking(doneRef, sources.CommonSourceUnit{ID: "x"}, time.Now())
+	c.Finish(doneRef)
+
+	orgRef := sources.JobProgressRef{SourceName: "org"}
+	c.EndUnitChunking(orgRef, sources.CommonSourceUnit{ID: "repo-a"}, time.Now())
+	interrupted.SetResumeInfo("repo-b")
+	drained := false
+	require.NoError(t, c.Commit(func() { drained = true }))
+	assert.True(t, drained)
+
+	// repo-b finished chunking, but its chunks were never scanned.
+	c.EndUnitChunking(orgRef, sources.CommonSourceUnit{ID: "repo-b"}, time.Now())
+	interrupted.SetResumeInfo("repo-c")
+	cancel()
+	// Wait for the final write triggered by the interruption.
+	c.wg.Wait()
+	c.Finish(orgRef)
+	require.NoError(t, c.Commit(nil))
+	require.NoError(t, c.Close())
+
+	// Second run.
+	resumed, err := Load(filename)
+	require.NoError(t, err)
+	assert.False(t, resumed.Track("done", &fakeSource{}))
+
+	org := &fakeSource{}
+	assert.True(t, resumed.Track("org", org))
+	assert.Equal(t, "repo-b", org.ResumeInfo())
+
+	var units unitCollector
+	reporter := resumed.SkipCompleted("org", &units)
+	for _, id := range []string{"repo-a", "repo-b", "repo-c"} {
+		require.NoError(t, reporter.UnitOk(ctx, sources.CommonSourceUnit{ID: id}))
+	}
+	assert.Equal(t, []string{"repo-b", "repo-c"}, units.units)
+}
+
+func TestCheckpointStagesUntilCommit(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "scan.checkpoint")
+	c := New(filename)
+	src := &fakeSource{}
+	c.Track("org", src)
+	ref := sources.JobProgressRef{SourceName: "org"}
+
+	c.EndUnitChunking(ref, sources.CommonSourceUnit{ID: "repo-a"}, time.Now())
+	c.Finish(ref)
+	// A periodic write before the engine drained records nothing.
+	require.NoError(t, c.Save())
+	loaded, err := Load(filename)
+	require.NoError(t, err)
+	assert.False(t, loaded.Completed("org", sources.CommonSourceUnit{ID: "repo-a"}))
+	assert.True(t, loaded.Track("org", &fakeSource{}))
+
+	// Units that end while the engine drains belong to the next commit.
+	require.NoError(t, c.Commit(func() {
+		c.EndUnitChunking(ref, sources.CommonSourceUnit{ID: "repo-b"}, time.Now())
+	}))
+	loaded, err = Load(filename)
+	require.NoError(t, err)
+	assert.True(t, loaded.Completed("org", sources.CommonSourceUnit{ID: "repo-a"}))
+	assert.False(t, loaded.Completed("org", sources.CommonSourceUnit{ID: "repo-b"}))
+	assert.False(t, loaded.Track("org", &fakeSource{}))
+}
+
+func TestCheckpointSkipsFailedUnits(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "scan.checkpoint")
+	c := New(filename)
+	c.Track("org", &fakeSource{})
+	ref := sources.JobProgressRef{SourceName: "org"}
+
+	failed := sources.CommonSourceUnit{ID: "repo-a"}
+	c.ReportError(ref, sources.ChunkError{Unit: failed, Err: errors.New("clone failed")})
+	c.EndUnitChunking(ref, failed, time.Now())
+
+	// The error can also arrive after the unit ended.
+	late := sources.CommonSourceUnit{ID: "repo-b"}
+	c.EndUnitChunking(ref, late, time.Now())
+	require.NoError(t, c.Commit(func() {
+		c.ReportError(ref, fmt.Errorf("reporting chunk: %w", sources.ChunkError{Unit: late, Err: errors.New("timeout")}))
+	}))
+
+	ok := sources.CommonSourceUnit{ID: "repo-c"}
+	c.EndUnitChunking(ref, ok, time.Now())
+	c.Finish(ref)
+	require.NoError(t, c.Commit(nil))
+
+	loaded, err := Load(filename)
+	require.NoError(t, err)
+	assert.False(t, loaded.Completed("org", failed))
+	assert.False(t, loaded.Completed("org", late))
+	assert.True(t, loaded.Completed("org", ok))
+	// A source with errors is never done.
+	assert.True(t, loaded.Track("org", &fakeSource{}))
+}
+
+func TestCheckpointPlainError(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "scan.checkpoint")
+	c := New(filename)
+	c.Track("org", &fakeSource{})
+	ref := sources.JobProgressRef{SourceName: "org"}
+
+	// An error that is not a ChunkError names no unit, so the unit is still
+	// recorded, but the source is not.
+	unit := sources.CommonSourceUnit{ID: "repo-a"}
+	c.ReportError(ref, errors.New("rate limited"))
+	c.EndUnitChunking(ref, unit, time.Now())
+	c.Finish(ref)
+	require.NoError(t, c.Commit(nil))
+
+	loaded, err := Load(filename)
+	require.NoError(t, err)
+	assert.True(t, loaded.Completed("org", unit))
+	assert.True(t, loaded.Track("org", &fakeSource{}))
+}
+
+func TestCheckpointInterruptedDuringDrain(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	filename := filepath.Join(t.TempDir(), "scan.checkpoint")
+	c := New(filename)
+	c.Track("org", &fakeSource{})
+	c.Start(ctx, time.Hour, nil)
+	ref := sources.JobProgressRef{SourceName: "org"}
+
+	c.EndUnitChunking(ref, sources.CommonSourceUnit{ID: "repo-a"}, time.Now())
+	require.NoError(t, c.Commit(func() {
+		cancel()
+		c.wg.Wait()
+	}))
+	require.NoError(t, c.Close())
+
+	loaded, err := Load(filename)
+	require.NoError(t, err)
+	assert.False(t, loaded.Completed("org", sources.CommonSourceUnit{ID: "repo-a"}))
+}
+
+func TestCheckpointConcurrentProgress(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := New(filepath.Join(t.TempDir(), "scan.checkpoint"))
+	src := &fakeSource{}
+	c.Track("org", src)
+	c.Start(ctx, time.Millisecond, func() {})
+
+	// The source updates its resume info while the checkpoint is written.
+	for i := range 200 {
+		src.SetResumeInfo(fmt.Sprintf("repo-%d", i))
+		time.Sleep(50 * time.Microsecond)
+	}
+	require.NoError(t, c.Commit(nil))
+	require.NoError(t, c.Close())
+}
+
+func TestLoadErrors(t *testing.T) {
+	_, err := Load(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
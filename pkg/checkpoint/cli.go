@@ -0,0 +1,38 @@
+package checkpoint
+
+import (
+	"errors"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+)
+
+var (
+	checkpointFile *string
+	resumeFile     *string
+	interval       *time.Duration
+)
+
+// Flags registers the checkpoint flags on app.
+func Flags(app *kingpin.Application) {
+	checkpointFile = app.Flag("checkpoint", "Periodically record the progress of the scan in this file.").String()
+	resumeFile = app.Flag("resume", "Continue the scan recorded in this checkpoint file, and keep recording to it.").String()
+	interval = app.Flag("checkpoint-interval", "How often the checkpoint file is written.").Default(DefaultInterval.String()).Duration()
+}
+
+// FromFlags returns the Checkpointer configured on the command line and the
+// interval to pass to Start. It returns nil if neither --checkpoint nor
+// --resume is set.
+func FromFlags() (*Checkpointer, time.Duration, error) {
+	switch {
+	case *resumeFile != "" && *checkpointFile != "" && *resumeFile != *checkpointFile:
+		return nil, 0, errors.New("--resume and --checkpoint must name the same file")
+	case *resumeFile != "":
+		c, err := Load(*resumeFile)
+		return c, *interval, err
+	case *checkpointFile != "":
+		return New(*checkpointFile), *interval, nil
+	default:
+		return nil, 0, nil
+	}
+}
@@ -1,6 +1,7 @@
 package common
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"io"
@@ -98,7 +99,23 @@ func userAgent() string {
 
 func (t *CustomTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	req.Header.Add("User-Agent", userAgent())
-	return t.T.RoundTrip(req)
+	observe, ok := req.Context().Value(roundTripObserverKey{}).(func(time.Duration))
+	if !ok {
+		return t.T.RoundTrip(req)
+	}
+	start := time.Now()
+	resp, err := t.T.RoundTrip(req)
+	observe(time.Since(start))
+	return resp, err
+}
+
+type roundTripObserverKey struct{}
+
+// WithRoundTripObserver returns a context that makes every request sent with
+// it through a CustomTransport report the duration of its round trip to
+// observe. observe may be called concurrently.
+func WithRoundTripObserver(ctx context.Context, observe func(time.Duration)) context.Context {
+	return context.WithValue(ctx, roundTripObserverKey{}, observe)
 }
 
 func NewCustomTransport(T http.RoundTripper) *CustomTransport {
@@ -0,0 +1,49 @@
+package detectorprofile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alecthomas/kingpin/v2"
+)
+
+var reportPath *string
+
+// Flags registers the profiling flag on app.
+func Flags(app *kingpin.Application) {
+	reportPath = app.Flag("profile-detectors", "Profile every detector and write a ranked report to this file when the scan ends. A .json extension writes JSON, - writes a table to stderr.").String()
+}
+
+// FromFlags returns a Profiler if --profile-detectors is set, and nil
+// otherwise.
+func FromFlags() *Profiler {
+	if *reportPath == "" {
+		return nil
+	}
+	return New()
+}
+
+// WriteReport writes the report of p to the file given by
+// --profile-detectors.
+func WriteReport(p *Profiler) error {
+	report := p.Report()
+	if *reportPath == "-" {
+		return report.WritePlain(os.Stderr)
+	}
+
+	f, err := os.Create(*reportPath)
+	if err != nil {
+		return fmt.Errorf("unable to create detector profile: %w", err)
+	}
+	if strings.EqualFold(filepath.Ext(*reportPath), ".json") {
+		err = report.WriteJSON(f)
+	} else {
+		err = report.WritePlain(f)
+	}
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("unable to write detector profile: %w", closeErr)
+	}
+	return err
+}
@@ -0,0 +1,196 @@
+// Package detectorprofile measures what each detector costs during a scan,
+// so decisions about which detectors to disable in custom builds can be based
+// on data.
+package detectorprofile
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+)
+
+// Profiler collects the statistics of the detectors the engine runs through
+// it. It is safe for concurrent use.
+//
+// The profiler measures around each call rather than wrapping detectors, so
+// the optional interfaces of a detector, such as detectors.Versioner, stay
+// visible to the rest of the engine while profiling is enabled.
+type Profiler struct {
+	mu    sync.RWMutex
+	stats map[detectorKey]*stats
+}
+
+// detectorKey identifies a detector in the report. Versioned detectors share
+// a type, so the version is part of the key.
+type detectorKey struct {
+	typ     detectorspb.DetectorType
+	version int
+}
+
+func keyOf(d detectors.Detector) detectorKey {
+	k := detectorKey{typ: d.Type()}
+	if v, ok := d.(detectors.Versioner); ok {
+		k.version = v.Version()
+	}
+	return k
+}
+
+func (k detectorKey) String() string {
+	if k.version > 0 {
+		return fmt.Sprintf("%s v%d", k.typ, k.version)
+	}
+	return k.typ.String()
+}
+
+type stats struct {
+	calls         atomic.Uint64
+	results       atomic.Uint64
+	errors        atomic.Uint64
+	detectTime    atomic.Int64
+	verifications atomic.Uint64
+	verifyTime    atomic.Int64
+	maxVerifyTime atomic.Int64
+}
+
+// New creates an empty Profiler.
+func New() *Profiler {
+	return &Profiler{stats: make(map[detectorKey]*stats)}
+}
+
+func (p *Profiler) statsFor(d detectors.Detector) *stats {
+	k := keyOf(d)
+	p.mu.RLock()
+	s, ok := p.stats[k]
+	p.mu.RUnlock()
+	if ok {
+		return s
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if s, ok = p.stats[k]; !ok {
+		s = &stats{}
+		p.stats[k] = s
+	}
+	return s
+}
+
+// FromData calls d.FromData and records the call. The engine calls it in
+// place of d.FromData.
+func (p *Profiler) FromData(ctx context.Context, d detectors.Detector, verify bool, data []byte) ([]detectors.Result, error) {
+	return p.Call(ctx, d, verify, func(ctx context.Context) ([]detectors.Result, error) {
+		return d.FromData(ctx, verify, data)
+	})
+}
+
+// Call runs fromData, which calls FromData of d with the given context, and
+// records the call, the number of results it returned and the time it took.
+// It lets the engine profile a call that is wrapped in other ways, such as
+// budget.Budget.FromData.
+//
+// Time spent waiting for HTTP round trips made through common.CustomTransport,
+// which is what detectors use to verify secrets, is recorded as verification
+// latency. The detection time is the wall-clock time of the call during which
+// no such round trip was in flight.
+func (p *Profiler) Call(ctx context.Context, d detectors.Detector, verify bool, fromData func(context.Context) ([]detectors.Result, error)) ([]detectors.Result, error) {
+	s := p.statsFor(d)
+	var waits waitTracker
+	if verify {
+		ctx = common.WithRoundTripObserver(ctx, func(latency time.Duration) {
+			waits.add(time.Now(), latency)
+			s.verifications.Add(1)
+			s.verifyTime.Add(int64(latency))
+			for {
+				cur := s.maxVerifyTime.Load()
+				if int64(latency) <= cur || s.maxVerifyTime.CompareAndSwap(cur, int64(latency)) {
+					break
+				}
+			}
+		})
+	}
+
+	start := time.Now()
+	results, err := fromData(ctx)
+	end := time.Now()
+	s.calls.Add(1)
+	s.results.Add(uint64(len(results)))
+	s.detectTime.Add(int64(end.Sub(start) - waits.busy(start, end)))
+	if err != nil {
+		s.errors.Add(1)
+	}
+	return results, err
+}
+
+// waitTracker records the intervals a call spent waiting for round trips.
+// Round trips may overlap, so the time spent waiting is the length of the
+// union of the intervals, not their sum.
+type waitTracker struct {
+	mu        sync.Mutex
+	intervals [][2]time.Time
+}
+
+// add records a round trip of the given latency that ended at end.
+func (w *waitTracker) add(end time.Time, latency time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.intervals = append(w.intervals, [2]time.Time{end.Add(-latency), end})
+}
+
+// busy returns how much of [start, end] was covered by round trips.
+func (w *waitTracker) busy(start, end time.Time) time.Duration {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	sort.Slice(w.intervals, func(i, j int) bool { return w.intervals[i][0].Before(w.intervals[j][0]) })
+
+	var total time.Duration
+	cursor := start
+	for _, iv := range w.intervals {
+		from, to := iv[0], iv[1]
+		if from.Before(cursor) {
+			from = cursor
+		}
+		if to.After(end) {
+			to = end
+		}
+		if to.After(from) {
+			total += to.Sub(from)
+			cursor = to
+		}
+	}
+	return total
+}
+
+// Report returns the statistics collected so far, ranked by detection time.
+func (p *Profiler) Report() Report {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var r Report
+	for k, s := range p.stats {
+		r.Detectors = append(r.Detectors, s.profile(k.String()))
+	}
+	sort.Slice(r.Detectors, func(i, j int) bool {
+		a, b := r.Detectors[i], r.Detectors[j]
+		if a.DetectTime != b.DetectTime {
+			return a.DetectTime > b.DetectTime
+		}
+		return a.Name < b.Name
+	})
+	for i := range r.Detectors {
+		r.Detectors[i].Rank = i + 1
+		r.TotalDetectTime += r.Detectors[i].DetectTime
+	}
+	if r.TotalDetectTime > 0 {
+		for i := range r.Detectors {
+			r.Detectors[i].DetectTimePercent = 100 * float64(r.Detectors[i].DetectTime) / float64(r.TotalDetectTime)
+		}
+	}
+	return r
+}
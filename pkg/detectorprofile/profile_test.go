@@ -0,0 +1,178 @@
+package detectorprofile
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+)
+
+// fakeDetector returns one result per occurrence of "secret" in the data and
+// verifies each of them with a request to url.
+type fakeDetector struct {
+	typ     detectorspb.DetectorType
+	url     string
+	version int
+}
+
+func (d fakeDetector) FromData(ctx context.Context, verify bool, data []byte) ([]detectors.Result, error) {
+	if string(data) == "fail" {
+		return nil, errors.New("failed")
+	}
+	var results []detectors.Result
+	for range strings.Count(string(data), "secret") {
+		r := detectors.Result{DetectorType: d.typ}
+		if verify {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.url, nil)
+			if err != nil {
+				return nil, err
+			}
+			resp, err := common.SaneHttpClient().Do(req)
+			if err != nil {
+				return nil, err
+			}
+			resp.Body.Close()
+			r.Verified = resp.StatusCode == http.StatusOK
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+func (d fakeDetector) Keywords() []string             { return []string{"secret"} }
+func (d fakeDetector) Type() detectorspb.DetectorType { return d.typ }
+func (fakeDetector) Description() string              { return "" }
+
+type versionedDetector struct{ fakeDetector }
+
+func (d versionedDetector) Version() int { return d.version }
+
+func TestProfiler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	p := New()
+	aws := fakeDetector{typ: detectorspb.DetectorType_AWS, url: server.URL}
+	slack := versionedDetector{fakeDetector{typ: detectorspb.DetectorType_Slack, version: 2}}
+
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results, err := p.FromData(ctx, aws, true, []byte("secret secret"))
+			assert.NoError(t, err)
+			assert.Len(t, results, 2)
+		}()
+	}
+	wg.Wait()
+
+	_, err := p.FromData(ctx, slack, false, []byte("fail"))
+	assert.Error(t, err)
+
+	report := p.Report()
+	require.Len(t, report.Detectors, 2)
+	awsProfile := report.Detectors[0]
+	assert.Equal(t, 1, awsProfile.Rank)
+	assert.Equal(t, "AWS", awsProfile.Name)
+	assert.Equal(t, uint64(3), awsProfile.Calls)
+	assert.Equal(t, uint64(6), awsProfile.Results)
+	assert.Equal(t, uint64(6), awsProfile.Verifications)
+	assert.GreaterOrEqual(t, time.Duration(awsProfile.AvgVerifyTime), 20*time.Millisecond)
+	assert.GreaterOrEqual(t, awsProfile.MaxVerifyTime, awsProfile.AvgVerifyTime)
+	// The verification round trips are not counted as detection time.
+	assert.Less(t, time.Duration(awsProfile.DetectTime), 6*20*time.Millisecond)
+
+	slackProfile := report.Detectors[1]
+	assert.Equal(t, "Slack v2", slackProfile.Name)
+	assert.Equal(t, uint64(1), slackProfile.Calls)
+	assert.Equal(t, uint64(1), slackProfile.Errors)
+	assert.Zero(t, slackProfile.Verifications)
+
+	var out bytes.Buffer
+	require.NoError(t, report.WriteJSON(&out))
+	var decoded Report
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, report, decoded)
+
+	out.Reset()
+	require.NoError(t, report.WritePlain(&out))
+	assert.Contains(t, out.String(), "DETECT TIME")
+	assert.Contains(t, out.String(), "Slack v2")
+}
+
+func TestWaitTracker(t *testing.T) {
+	start := time.Unix(0, 0)
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+
+	var w waitTracker
+	// Two overlapping round trips and a separate one.
+	w.add(at(30), 20*time.Millisecond)
+	w.add(at(40), 20*time.Millisecond)
+	w.add(at(80), 10*time.Millisecond)
+	assert.Equal(t, 40*time.Millisecond, w.busy(start, at(100)))
+	// Time outside of the call is not counted.
+	assert.Equal(t, 20*time.Millisecond, w.busy(at(25), at(75)))
+
+	var empty waitTracker
+	assert.Zero(t, empty.busy(start, at(100)))
+}
+
+// parallelDetector verifies two candidates concurrently.
+type parallelDetector struct{ fakeDetector }
+
+func (d parallelDetector) FromData(ctx context.Context, verify bool, data []byte) ([]detectors.Result, error) {
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = d.fakeDetector.FromData(ctx, verify, data)
+		}()
+	}
+	wg.Wait()
+	return nil, nil
+}
+
+func TestProfilerCall(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	p := New()
+	d := parallelDetector{fakeDetector{typ: detectorspb.DetectorType_AWS, url: server.URL}}
+	// Call lets another wrapper sit between the profiler and the detector.
+	called := false
+	_, err := p.Call(context.Background(), d, true, func(ctx context.Context) ([]detectors.Result, error) {
+		called = true
+		return d.FromData(ctx, true, []byte("secret"))
+	})
+	require.NoError(t, err)
+	assert.True(t, called)
+
+	report := p.Report()
+	require.Len(t, report.Detectors, 1)
+	profile := report.Detectors[0]
+	assert.Equal(t, uint64(2), profile.Verifications)
+	// The concurrent round trips overlap: subtracting their sum from the
+	// call's duration would go negative.
+	assert.Greater(t, time.Duration(profile.DetectTime), time.Duration(0))
+	assert.Less(t, time.Duration(profile.DetectTime), 50*time.Millisecond)
+}
@@ -0,0 +1,93 @@
+package detectorprofile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/summary"
+)
+
+// Report is the ranked result of a profiling run.
+type Report struct {
+	TotalDetectTime summary.Duration  `json:"total_detect_time"`
+	Detectors       []DetectorProfile `json:"detectors"`
+}
+
+// DetectorProfile is what a single detector cost during the scan.
+type DetectorProfile struct {
+	Rank int    `json:"rank"`
+	Name string `json:"name"`
+	// Calls is how often FromData was called. The engine only calls a
+	// detector for chunks the keyword prefilter selected it for, so this is
+	// also the number of keyword hits.
+	Calls uint64 `json:"calls"`
+	// Results is the number of results FromData returned.
+	Results uint64 `json:"results"`
+	Errors  uint64 `json:"errors"`
+	// DetectTime is the wall-clock time spent in FromData while no
+	// verification round trip was in flight.
+	DetectTime        summary.Duration `json:"detect_time"`
+	AvgDetectTime     summary.Duration `json:"avg_detect_time"`
+	Verifications     uint64           `json:"verifications"`
+	AvgVerifyTime     summary.Duration `json:"avg_verification_latency"`
+	MaxVerifyTime     summary.Duration `json:"max_verification_latency"`
+	DetectTimePercent float64          `json:"detect_time_percent"`
+}
+
+func (s *stats) profile(name string) DetectorProfile {
+	p := DetectorProfile{
+		Name:          name,
+		Calls:         s.calls.Load(),
+		Results:       s.results.Load(),
+		Errors:        s.errors.Load(),
+		DetectTime:    summary.Duration(s.detectTime.Load()),
+		Verifications: s.verifications.Load(),
+		MaxVerifyTime: summary.Duration(s.maxVerifyTime.Load()),
+	}
+	if p.Calls > 0 {
+		p.AvgDetectTime = p.DetectTime / summary.Duration(p.Calls)
+	}
+	if p.Verifications > 0 {
+		p.AvgVerifyTime = summary.Duration(s.verifyTime.Load()) / summary.Duration(p.Verifications)
+	}
+	return p
+}
+
+// WriteJSON writes the report as a single indented JSON document.
+func (r Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r); err != nil {
+		return fmt.Errorf("could not write detector profile: %w", err)
+	}
+	return nil
+}
+
+// WritePlain writes the report as a human readable table, most expensive
+// detector first.
+func (r Report) WritePlain(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "Detector profile")
+	fmt.Fprintf(tw, "  Total detection time:\t%s\n", r.TotalDetectTime)
+	fmt.Fprintln(tw, "\nRANK\tDETECTOR\tCALLS\tRESULTS\tERRORS\tDETECT TIME\t% DETECT\tAVG DETECT/CALL\tVERIFICATIONS\tAVG VERIFY\tMAX VERIFY")
+	for _, d := range r.Detectors {
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%d\t%s\t%.1f\t%s\t%d\t%s\t%s\n",
+			d.Rank, d.Name, d.Calls, d.Results, d.Errors,
+			round(d.DetectTime), d.DetectTimePercent, round(d.AvgDetectTime),
+			d.Verifications, round(d.AvgVerifyTime), round(d.MaxVerifyTime))
+	}
+
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("could not write detector profile: %w", err)
+	}
+	return nil
+}
+
+// round shortens durations to a precision that is readable in a table.
+func round(d summary.Duration) time.Duration {
+	return time.Duration(d).Round(time.Microsecond)
+}
@@ -0,0 +1,57 @@
+package detectors
+
+import "bytes"
+
+// Position is the location of a match within the original object, such as a
+// file, a bucket object or an archive member. Line and Column are 1-based,
+// Offset is the 0-based byte offset from the start of the object.
+type Position struct {
+	Line   int64
+	Column int64
+	Offset int64
+}
+
+// ObjectStart is the position of the first byte of an object.
+var ObjectStart = Position{Line: 1, Column: 1}
+
+// PositionOf returns the position of the first occurrence of match in data.
+// start is the position of data[0] within the original object, which lets
+// sources that split an object into several chunks report positions relative
+// to the object rather than to the chunk.
+func PositionOf(data, match []byte, start Position) (Position, bool) {
+	if len(match) == 0 {
+		return Position{}, false
+	}
+	idx := bytes.Index(data, match)
+	if idx < 0 {
+		return Position{}, false
+	}
+
+	before := data[:idx]
+	pos := Position{
+		Line:   start.Line + int64(bytes.Count(before, []byte("\n"))),
+		Offset: start.Offset + int64(idx),
+	}
+	if nl := bytes.LastIndexByte(before, '\n'); nl >= 0 {
+		pos.Column = int64(idx - nl)
+	} else {
+		// The match is on the line the chunk started in.
+		pos.Column = start.Column + int64(idx)
+	}
+	return pos, true
+}
+
+// Advance returns the position right after data, assuming data starts at p.
+// Sources use it to track where the next chunk of an object begins.
+func (p Position) Advance(data []byte) Position {
+	next := Position{
+		Line:   p.Line + int64(bytes.Count(data, []byte("\n"))),
+		Offset: p.Offset + int64(len(data)),
+	}
+	if nl := bytes.LastIndexByte(data, '\n'); nl >= 0 {
+		next.Column = int64(len(data) - nl)
+	} else {
+		next.Column = p.Column + int64(len(data))
+	}
+	return next
+}
@@ -0,0 +1,30 @@
+package detectors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPositionOf(t *testing.T) {
+	object := []byte("first line\nsecond AKIAEXAMPLE\nthird")
+
+	pos, ok := PositionOf(object, []byte("AKIAEXAMPLE"), ObjectStart)
+	assert.True(t, ok)
+	assert.Equal(t, Position{Line: 2, Column: 8, Offset: 18}, pos)
+
+	// Splitting the object into chunks must not change the position.
+	for _, split := range []int{5, 11, 20} {
+		first, second := object[:split], object[split:]
+		pos, ok := PositionOf(second, []byte("AKIAEXAMPLE"), ObjectStart.Advance(first))
+		if split > 18 {
+			assert.False(t, ok)
+			continue
+		}
+		assert.True(t, ok)
+		assert.Equal(t, Position{Line: 2, Column: 8, Offset: 18}, pos, "split at %d", split)
+	}
+
+	_, ok = PositionOf(object, []byte("missing"), ObjectStart)
+	assert.False(t, ok)
+}
@@ -0,0 +1,52 @@
+package detectors
+
+import (
+	"sync"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+)
+
+// Remediation tells responders how to get rid of a leaked secret. Fields a
+// provider has no equivalent for are empty.
+type Remediation struct {
+	// Product is the product or service the secret belongs to.
+	Product string `json:"product,omitempty"`
+	// RotationURL is where the owner of the secret can replace it.
+	RotationURL string `json:"rotation_url,omitempty"`
+	// RevocationEndpoint is an API endpoint that revokes the secret.
+	RevocationEndpoint string `json:"revocation_endpoint,omitempty"`
+	// DocsURL links to the provider's documentation on rotating secrets.
+	DocsURL string `json:"docs_url,omitempty"`
+}
+
+// Remediator is an optional interface for detectors that know how the secrets
+// they find are rotated and revoked.
+type Remediator interface {
+	Remediation() Remediation
+}
+
+var (
+	remediationsMu sync.RWMutex
+	remediations   = make(map[detectorspb.DetectorType]Remediation)
+)
+
+// RegisterRemediation makes the remediation of d available to RemediationFor
+// if d implements Remediator. Results only carry their detector type, so the
+// engine registers every detector it loads for the printers to look up.
+func RegisterRemediation(d Detector) {
+	r, ok := d.(Remediator)
+	if !ok {
+		return
+	}
+	remediationsMu.Lock()
+	defer remediationsMu.Unlock()
+	remediations[d.Type()] = r.Remediation()
+}
+
+// RemediationFor returns the remediation registered for detectors of type t.
+func RemediationFor(t detectorspb.DetectorType) (Remediation, bool) {
+	remediationsMu.RLock()
+	defer remediationsMu.RUnlock()
+	r, ok := remediations[t]
+	return r, ok
+}
@@ -21,6 +21,7 @@ type Scanner struct {
 
 // Check that the Slack scanner implements the SecretScanner interface at compile time.
 var _ detectors.Detector = Scanner{}
+var _ detectors.Remediator = Scanner{}
 
 var (
 	defaultClient = common.SaneHttpClient()
@@ -132,3 +133,14 @@ func (s Scanner) Type() detectorspb.DetectorType {
 func (s Scanner) Description() string {
 	return "Slack tokens can be used to authenticate API requests to the Slack platform, allowing access to various workspace resources and functionalities."
 }
+
+// Remediation returns where Slack tokens are regenerated and revoked. A token
+// revokes itself when it is sent to auth.revoke.
+func (s Scanner) Remediation() detectors.Remediation {
+	return detectors.Remediation{
+		Product:            "Slack",
+		RotationURL:        "https://api.slack.com/apps",
+		RevocationEndpoint: "https://slack.com/api/auth.revoke",
+		DocsURL:            "https://api.slack.com/authentication/rotation",
+	}
+}
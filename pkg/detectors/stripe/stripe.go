@@ -14,8 +14,9 @@ import (
 
 type Scanner struct{}
 
-// Ensure the Scanner satisfies the interface at compile time.
+// Ensure the Scanner satisfies the interfaces at compile time.
 var _ detectors.Detector = (*Scanner)(nil)
+var _ detectors.Remediator = (*Scanner)(nil)
 
 var (
 	// doesn't include test keys with "sk_test"
@@ -82,3 +83,13 @@ func (s Scanner) Type() detectorspb.DetectorType {
 func (s Scanner) Description() string {
 	return "Stripe is a payment processing platform. Stripe API keys can be used to interact with Stripe's services for processing payments, managing subscriptions, and more."
 }
+
+// Remediation returns where Stripe keys are rolled. Stripe has no API for
+// revoking a secret key, so it has to be rolled in the dashboard.
+func (s Scanner) Remediation() detectors.Remediation {
+	return detectors.Remediation{
+		Product:     "Stripe",
+		RotationURL: "https://dashboard.stripe.com/apikeys",
+		DocsURL:     "https://docs.stripe.com/keys#rolling-keys",
+	}
+}
@@ -0,0 +1,139 @@
+package findings
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+var (
+	dbPath       *string
+	detectorType *string
+	repository   *string
+	scanID       *string
+	onlyVerified *bool
+
+	listCmd      *kingpin.CmdClause
+	exportCmd    *kingpin.CmdClause
+	exportFormat *string
+)
+
+// Command registers the findings subcommand and its flags on app.
+func Command(app *kingpin.Application) *kingpin.CmdClause {
+	cli := app.Command("findings", "Query findings recorded in a findings database.")
+	dbPath = cli.Flag("db", "Path to the findings database.").Default("trufflehog.db").String()
+	detectorType = cli.Flag("detector", "Only show findings from this detector type.").String()
+	repository = cli.Flag("repo", "Only show findings from this repository.").String()
+	scanID = cli.Flag("scan", "Only show findings seen in this scan.").String()
+	onlyVerified = cli.Flag("only-verified", "Only show findings that were verified when last seen.").Bool()
+
+	listCmd = cli.Command("list", "List findings as a table.").Default()
+	exportCmd = cli.Command("export", "Export findings.")
+	exportFormat = exportCmd.Flag("format", "Export format.").Default("json").Enum("json", "csv")
+
+	return cli
+}
+
+// Run executes the findings subcommand selected by cmd.
+func Run(ctx context.Context, cmd string) error {
+	store, err := Open(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	found, err := store.List(ctx, Filter{
+		DetectorType: *detectorType,
+		Repository:   *repository,
+		ScanID:       *scanID,
+		OnlyVerified: *onlyVerified,
+	})
+	if err != nil {
+		return err
+	}
+
+	switch cmd {
+	case listCmd.FullCommand():
+		return writeTable(os.Stdout, found)
+	case exportCmd.FullCommand():
+		if *exportFormat == "csv" {
+			return writeCSV(os.Stdout, found)
+		}
+		return writeJSON(os.Stdout, found)
+	default:
+		return fmt.Errorf("unknown findings command %q", cmd)
+	}
+}
+
+func writeTable(w io.Writer, found []Finding) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FINGERPRINT\tDETECTOR\tVERIFIED\tLOCATION\tFIRST SEEN\tLAST SEEN\tSCANS")
+	for _, f := range found {
+		fmt.Fprintf(tw, "%s\t%s\t%t\t%s\t%s\t%s\t%d\n",
+			shortFingerprint(f.Fingerprint), f.DetectorType, f.Verified, location(f),
+			f.FirstSeenScan, f.LastSeenScan, f.ScanCount)
+	}
+	return tw.Flush()
+}
+
+func writeJSON(w io.Writer, found []Finding) error {
+	enc := json.NewEncoder(w)
+	for _, f := range found {
+		if err := enc.Encode(f); err != nil {
+			return fmt.Errorf("could not write finding: %w", err)
+		}
+	}
+	return nil
+}
+
+func writeCSV(w io.Writer, found []Finding) error {
+	cw := csv.NewWriter(w)
+	header := []string{
+		"fingerprint", "detector_type", "decoder_type", "verified", "redacted",
+		"source_type", "source_name", "file", "line", "commit", "repository", "email", "link",
+		"first_seen_scan", "last_seen_scan", "first_seen_at", "last_seen_at", "scan_count",
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, f := range found {
+		row := []string{
+			f.Fingerprint, f.DetectorType, f.DecoderType, strconv.FormatBool(f.Verified), f.Redacted,
+			f.SourceType, f.SourceName, f.File, strconv.FormatInt(f.Line, 10), f.Commit, f.Repository, f.Email, f.Link,
+			f.FirstSeenScan, f.LastSeenScan, f.FirstSeenAt.Format(time.RFC3339), f.LastSeenAt.Format(time.RFC3339),
+			strconv.Itoa(f.ScanCount),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func shortFingerprint(fp string) string {
+	if len(fp) > 12 {
+		return fp[:12]
+	}
+	return fp
+}
+
+func location(f Finding) string {
+	loc := f.File
+	if f.Repository != "" {
+		loc = f.Repository + " " + loc
+	}
+	if f.Line > 0 {
+		loc = fmt.Sprintf("%s:%d", loc, f.Line)
+	}
+	return loc
+}
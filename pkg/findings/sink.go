@@ -0,0 +1,44 @@
+package findings
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+)
+
+// Sink is a printer that records every result in a Store under a single
+// scan ID.
+type Sink struct {
+	store  *Store
+	scanID string
+}
+
+// NewScanID returns an identifier for a new scan. IDs sort chronologically
+// and end with a random suffix, so scans started in the same millisecond,
+// e.g. by scheduled jobs, still get distinct IDs.
+func NewScanID() string {
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return time.Now().UTC().Format("20060102T150405.000Z") + "-" + hex.EncodeToString(suffix)
+}
+
+// NewSink creates a Sink for the given scan and records the start of the scan
+// in the store.
+func NewSink(ctx context.Context, store *Store, scanID string) (*Sink, error) {
+	if err := store.BeginScan(ctx, scanID); err != nil {
+		return nil, err
+	}
+	return &Sink{store: store, scanID: scanID}, nil
+}
+
+func (s *Sink) Print(ctx context.Context, r *detectors.ResultWithMetadata) error {
+	return s.store.Record(ctx, s.scanID, r)
+}
+
+// Close marks the scan as finished. It does not close the underlying store.
+func (s *Sink) Close() error {
+	return s.store.FinishScan(context.Background(), s.scanID)
+}
@@ -0,0 +1,273 @@
+package findings
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/output"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS scans (
+	id          TEXT PRIMARY KEY,
+	started_at  TEXT NOT NULL,
+	finished_at TEXT
+);
+
+CREATE TABLE IF NOT EXISTS findings (
+	fingerprint     TEXT PRIMARY KEY,
+	detector_type   TEXT NOT NULL,
+	decoder_type    TEXT NOT NULL,
+	verified        INTEGER NOT NULL,
+	redacted        TEXT NOT NULL,
+	source_type     TEXT NOT NULL,
+	source_name     TEXT NOT NULL,
+	file            TEXT NOT NULL,
+	line            INTEGER NOT NULL,
+	commit_hash     TEXT NOT NULL,
+	repository      TEXT NOT NULL,
+	email           TEXT NOT NULL,
+	link            TEXT NOT NULL,
+	first_seen_scan TEXT NOT NULL,
+	last_seen_scan  TEXT NOT NULL,
+	first_seen_at   TEXT NOT NULL,
+	last_seen_at    TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS sightings (
+	fingerprint TEXT NOT NULL,
+	scan_id     TEXT NOT NULL,
+	verified    INTEGER NOT NULL,
+	PRIMARY KEY (fingerprint, scan_id)
+);
+
+CREATE INDEX IF NOT EXISTS findings_detector_type ON findings (detector_type);
+CREATE INDEX IF NOT EXISTS findings_repository ON findings (repository);
+CREATE INDEX IF NOT EXISTS sightings_scan_id ON sightings (scan_id);
+`
+
+// Store persists findings in a SQLite database so that they can be tracked
+// across scans. Every finding is keyed by a fingerprint that is stable
+// between runs and records the first and last scan it was seen in.
+type Store struct {
+	db *sql.DB
+}
+
+// Finding is a single finding as recorded in the Store.
+type Finding struct {
+	Fingerprint   string    `json:"fingerprint"`
+	DetectorType  string    `json:"detector_type"`
+	DecoderType   string    `json:"decoder_type"`
+	Verified      bool      `json:"verified"`
+	Redacted      string    `json:"redacted"`
+	SourceType    string    `json:"source_type"`
+	SourceName    string    `json:"source_name"`
+	File          string    `json:"file"`
+	Line          int64     `json:"line"`
+	Commit        string    `json:"commit"`
+	Repository    string    `json:"repository"`
+	Email         string    `json:"email"`
+	Link          string    `json:"link"`
+	FirstSeenScan string    `json:"first_seen_scan"`
+	LastSeenScan  string    `json:"last_seen_scan"`
+	FirstSeenAt   time.Time `json:"first_seen_at"`
+	LastSeenAt    time.Time `json:"last_seen_at"`
+	ScanCount     int       `json:"scan_count"`
+}
+
+// Filter restricts the findings returned by List. Zero values match
+// everything.
+type Filter struct {
+	DetectorType string
+	// Repository is normalized like the stored repositories, so any clone
+	// URL of a repository matches its findings.
+	Repository string
+	// ScanID only matches findings that were seen in the given scan.
+	ScanID       string
+	OnlyVerified bool
+}
+
+// busyTimeout is how long a connection waits for another process that holds
+// the database lock, e.g. a concurrent scan, before failing with SQLITE_BUSY.
+const busyTimeout = 10 * time.Second
+
+// Open opens the findings database at path, creating it and its schema if
+// necessary.
+func Open(path string) (*Store, error) {
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(%d)", path, busyTimeout.Milliseconds())
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open findings database: %w", err)
+	}
+	// SQLite only supports a single writer, so serialize access instead of
+	// surfacing "database is locked" errors to concurrent printers. Writers
+	// in other processes are waited for through the busy timeout.
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(schema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("unable to initialize findings database: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the underlying database.
+func (s *Store) Close() error { return s.db.Close() }
+
+// BeginScan records the start of a scan. It fails if the scan ID was used
+// before, so that two scans are never merged.
+func (s *Store) BeginScan(ctx context.Context, scanID string) error {
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO scans (id, started_at) VALUES (?, ?) ON CONFLICT (id) DO NOTHING`,
+		scanID, formatTime(time.Now()),
+	)
+	if err != nil {
+		return fmt.Errorf("unable to record scan %q: %w", scanID, err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("unable to record scan %q: %w", scanID, err)
+	} else if n == 0 {
+		return fmt.Errorf("scan %q already exists in the findings database", scanID)
+	}
+	return nil
+}
+
+// FinishScan records the end of a scan.
+func (s *Store) FinishScan(ctx context.Context, scanID string) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE scans SET finished_at = ? WHERE id = ?`,
+		formatTime(time.Now()), scanID,
+	)
+	if err != nil {
+		return fmt.Errorf("unable to finish scan %q: %w", scanID, err)
+	}
+	return nil
+}
+
+// Record inserts or updates the finding for r and notes that it was seen in
+// the given scan.
+func (s *Store) Record(ctx context.Context, scanID string, r *detectors.ResultWithMetadata) error {
+	loc, err := output.LocationOf(r)
+	if err != nil {
+		return fmt.Errorf("unable to read result metadata: %w", err)
+	}
+	fingerprint, err := output.Fingerprint(r)
+	if err != nil {
+		return fmt.Errorf("unable to fingerprint result: %w", err)
+	}
+	now := formatTime(time.Now())
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO findings (
+			fingerprint, detector_type, decoder_type, verified, redacted,
+			source_type, source_name, file, line, commit_hash, repository, email, link,
+			first_seen_scan, last_seen_scan, first_seen_at, last_seen_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (fingerprint) DO UPDATE SET
+			verified = excluded.verified,
+			redacted = excluded.redacted,
+			source_name = excluded.source_name,
+			link = excluded.link,
+			last_seen_scan = excluded.last_seen_scan,
+			last_seen_at = excluded.last_seen_at`,
+		fingerprint, r.DetectorType.String(), r.DecoderType.String(), r.Verified, r.Redacted,
+		r.SourceType.String(), r.SourceName, loc.File, loc.Line, loc.Commit, output.NormalizeRepository(loc.Repository), loc.Email, loc.Link,
+		scanID, scanID, now, now,
+	)
+	if err != nil {
+		return fmt.Errorf("unable to record finding: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO sightings (fingerprint, scan_id, verified) VALUES (?, ?, ?)
+		ON CONFLICT (fingerprint, scan_id) DO UPDATE SET verified = MAX(verified, excluded.verified)`,
+		fingerprint, scanID, r.Verified,
+	)
+	if err != nil {
+		return fmt.Errorf("unable to record sighting: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// List returns the findings matching f, most recently seen first.
+func (s *Store) List(ctx context.Context, f Filter) ([]Finding, error) {
+	var (
+		where []string
+		args  []any
+	)
+	if f.DetectorType != "" {
+		where = append(where, "f.detector_type = ?")
+		args = append(args, f.DetectorType)
+	}
+	if f.Repository != "" {
+		where = append(where, "f.repository = ?")
+		args = append(args, output.NormalizeRepository(f.Repository))
+	}
+	if f.ScanID != "" {
+		where = append(where, "EXISTS (SELECT 1 FROM sightings x WHERE x.fingerprint = f.fingerprint AND x.scan_id = ?)")
+		args = append(args, f.ScanID)
+	}
+	if f.OnlyVerified {
+		where = append(where, "f.verified = 1")
+	}
+
+	query := `
+		SELECT
+			f.fingerprint, f.detector_type, f.decoder_type, f.verified, f.redacted,
+			f.source_type, f.source_name, f.file, f.line, f.commit_hash, f.repository, f.email, f.link,
+			f.first_seen_scan, f.last_seen_scan, f.first_seen_at, f.last_seen_at,
+			(SELECT COUNT(*) FROM sightings s WHERE s.fingerprint = f.fingerprint)
+		FROM findings f`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY f.last_seen_at DESC, f.fingerprint"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query findings: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Finding
+	for rows.Next() {
+		var (
+			finding                 Finding
+			firstSeenAt, lastSeenAt string
+		)
+		err := rows.Scan(
+			&finding.Fingerprint, &finding.DetectorType, &finding.DecoderType, &finding.Verified, &finding.Redacted,
+			&finding.SourceType, &finding.SourceName, &finding.File, &finding.Line, &finding.Commit,
+			&finding.Repository, &finding.Email, &finding.Link,
+			&finding.FirstSeenScan, &finding.LastSeenScan, &firstSeenAt, &lastSeenAt,
+			&finding.ScanCount,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read finding: %w", err)
+		}
+		finding.FirstSeenAt = parseTime(firstSeenAt)
+		finding.LastSeenAt = parseTime(lastSeenAt)
+		out = append(out, finding)
+	}
+	return out, rows.Err()
+}
+
+func formatTime(t time.Time) string { return t.UTC().Format(time.RFC3339Nano) }
+
+func parseTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, s)
+	return t
+}
@@ -0,0 +1,59 @@
+package hook
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/git"
+)
+
+var (
+	preCommit  *kingpin.CmdClause
+	preReceive *kingpin.CmdClause
+
+	repoPath     *string
+	onlyVerified *bool
+)
+
+// Command registers the hook subcommand and its flags on app.
+func Command(app *kingpin.Application) *kingpin.CmdClause {
+	cli := app.Command("hook", "Run as a git hook and reject commits or pushes that contain secrets.")
+	repoPath = cli.Flag("repo-path", "Path to the repository.").Default(".").String()
+	onlyVerified = cli.Flag("only-verified", "Only block on verified secrets.").Bool()
+	preCommit = cli.Command("pre-commit", "Scan the staged changes. Install as .git/hooks/pre-commit.")
+	preReceive = cli.Command("pre-receive", "Scan pushed commits read from stdin. Install as hooks/pre-receive on the server.")
+	return cli
+}
+
+// NewFindingsFromFlags returns the printer for the engine that processes the
+// chunks of Run.
+func NewFindingsFromFlags() *Findings {
+	return NewFindings(*onlyVerified)
+}
+
+// Run scans what the hook selected by cmd, the full command returned by
+// kingpin's Parse, covers and reports the chunks to reporter. Once the engine
+// processed all chunks, Exit must be called to reject the commit or push.
+func Run(ctx context.Context, cmd string, g *git.Git, reporter sources.ChunkReporter) error {
+	switch cmd {
+	case preCommit.FullCommand():
+		return PreCommit(ctx, g, *repoPath, reporter)
+	case preReceive.FullCommand():
+		return PreReceive(ctx, g, *repoPath, os.Stdin, reporter)
+	default:
+		return fmt.Errorf("unknown hook %q", cmd)
+	}
+}
+
+// Exit writes the block message of findings to stderr, where git shows it to
+// the committer or pusher, and exits with ExitCode if there are findings. It
+// returns if the commit or push may go ahead.
+func Exit(findings *Findings) {
+	if findings.Block(os.Stderr) {
+		os.Exit(ExitCode)
+	}
+}
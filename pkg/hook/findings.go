@@ -0,0 +1,122 @@
+package hook
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/output"
+)
+
+// ExitCode is the exit code a hook uses to reject a commit or push.
+const ExitCode = 1
+
+// finding is what the block message shows about a result. The secret itself
+// is never kept, because the message ends up in terminals and server logs.
+type finding struct {
+	detector string
+	status   string
+	redacted string
+	loc      output.ResultLocation
+	rotate   string
+}
+
+// Findings is a printer that collects the results of a hook scan so they can
+// be reported in a single block message once the scan finished.
+type Findings struct {
+	onlyVerified bool
+
+	mu       sync.Mutex
+	findings []finding
+}
+
+// NewFindings creates a Findings printer. If onlyVerified is set, unverified
+// results do not block.
+func NewFindings(onlyVerified bool) *Findings {
+	return &Findings{onlyVerified: onlyVerified}
+}
+
+func (f *Findings) Print(_ context.Context, r *detectors.ResultWithMetadata) error {
+	if f.onlyVerified && !r.Verified {
+		return nil
+	}
+	loc, err := output.LocationOf(r)
+	if err != nil {
+		return fmt.Errorf("could not marshal result: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.findings = append(f.findings, finding{
+		detector: r.DetectorType.String(),
+		status:   output.VerificationStatus(r),
+		redacted: r.Redacted,
+		loc:      loc,
+		rotate:   rotationURL(r),
+	})
+	return nil
+}
+
+// Len returns the number of collected findings.
+func (f *Findings) Len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.findings)
+}
+
+// Block writes a message explaining why the commit or push is rejected to w
+// and reports whether it should be rejected. Nothing is written if there are
+// no findings. Callers exit with ExitCode when Block returns true.
+func (f *Findings) Block(w io.Writer) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.findings) == 0 {
+		return false
+	}
+
+	var b strings.Builder
+	b.WriteString("\nTruffleHog found secrets in your changes:\n\n")
+	for _, fd := range f.findings {
+		fmt.Fprintf(&b, "  %s (%s)\n", fd.detector, fd.status)
+		if where := location(fd.loc); where != "" {
+			fmt.Fprintf(&b, "    at:     %s\n", where)
+		}
+		if fd.loc.Commit != "" {
+			fmt.Fprintf(&b, "    commit: %s\n", fd.loc.Commit)
+		}
+		if fd.redacted != "" {
+			fmt.Fprintf(&b, "    secret: %s\n", fd.redacted)
+		}
+		if fd.rotate != "" {
+			fmt.Fprintf(&b, "    rotate: %s\n", fd.rotate)
+		}
+	}
+	fmt.Fprintf(&b, "\n%d secret(s) found, rejecting.\n", len(f.findings))
+	b.WriteString("Remove the secrets and rotate any that were real, or add a\n")
+	b.WriteString("`trufflehog:ignore` comment to the line if it is a false positive.\n")
+	_, _ = io.WriteString(w, b.String())
+	return true
+}
+
+func location(loc output.ResultLocation) string {
+	if loc.File == "" {
+		return ""
+	}
+	if loc.Line > 0 {
+		return fmt.Sprintf("%s:%d", loc.File, loc.Line)
+	}
+	return loc.File
+}
+
+// rotationURL returns where the secret of r can be rotated, if its detector
+// knows.
+func rotationURL(r *detectors.ResultWithMetadata) string {
+	rem, ok := detectors.RemediationFor(r.DetectorType)
+	if !ok {
+		return ""
+	}
+	return rem.RotationURL
+}
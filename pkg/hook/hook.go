@@ -0,0 +1,176 @@
+// Package hook implements git hook entry points. The pre-commit hook scans the
+// staged changes of a working tree and the pre-receive hook scans the commits
+// pushed to a server-side repository, so that secrets can be rejected before
+// they enter the history.
+package hook
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/git"
+)
+
+// zeroHash is the object name git uses for the missing side of a ref update.
+const zeroHash = "0000000000000000000000000000000000000000"
+
+// RefUpdate is one line of the pre-receive hook's standard input.
+type RefUpdate struct {
+	OldHash string
+	NewHash string
+	Ref     string
+}
+
+// IsDelete reports whether the update deletes the ref.
+func (u RefUpdate) IsDelete() bool { return isZeroHash(u.NewHash) }
+
+// IsCreate reports whether the update creates the ref.
+func (u RefUpdate) IsCreate() bool { return isZeroHash(u.OldHash) }
+
+func isZeroHash(hash string) bool {
+	return strings.Trim(hash, "0") == ""
+}
+
+// ParseRefUpdates reads the "<old> <new> <ref>" lines git passes to a
+// pre-receive hook.
+func ParseRefUpdates(r io.Reader) ([]RefUpdate, error) {
+	var updates []RefUpdate
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid ref update on line %d: %q", lineNum, line)
+		}
+		updates = append(updates, RefUpdate{OldHash: fields[0], NewHash: fields[1], Ref: fields[2]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read ref updates: %w", err)
+	}
+	return updates, nil
+}
+
+// PreCommit scans the changes staged in the working tree at repoPath.
+func PreCommit(ctx context.Context, g *git.Git, repoPath string, reporter sources.ChunkReporter) error {
+	repo, err := git.RepoFromPath(repoPath, false)
+	if err != nil {
+		return fmt.Errorf("could not open repository %s: %w", repoPath, err)
+	}
+	return g.ScanStaged(ctx, repo, repoPath, git.NewScanOptions(), reporter)
+}
+
+// PreReceive scans the commits introduced by each ref update read from r.
+// Deleted refs are skipped. For updated refs, the commits in old..new are
+// scanned. For created refs, the commits that are not yet reachable from any
+// existing ref are scanned.
+//
+// Each range is walked by a single git log run, which excludes the commits
+// reachable from the old head by the commit graph rather than by date, and
+// which sees the pushed objects in the hook's quarantine directory.
+func PreReceive(ctx context.Context, g *git.Git, repoPath string, r io.Reader, reporter sources.ChunkReporter) error {
+	updates, err := ParseRefUpdates(r)
+	if err != nil {
+		return err
+	}
+
+	bare, err := isBareRepository(ctx, repoPath)
+	if err != nil {
+		return err
+	}
+	repo, err := git.RepoFromPath(repoPath, bare)
+	if err != nil {
+		return fmt.Errorf("could not open repository %s: %w", repoPath, err)
+	}
+
+	for _, update := range updates {
+		if update.IsDelete() {
+			continue
+		}
+		logger := ctx.Logger().WithValues("ref", update.Ref)
+
+		commits, err := pushedCommits(ctx, repoPath, update)
+		if err != nil {
+			return err
+		}
+		if len(commits) == 0 {
+			logger.V(2).Info("ref update introduces no new commits, skipping")
+			continue
+		}
+
+		opts := git.NewScanOptions(
+			git.ScanOptionHeadCommit(update.NewHash),
+			git.ScanOptionBare(bare),
+		)
+		if update.IsCreate() {
+			tips, err := refTips(ctx, repoPath)
+			if err != nil {
+				return err
+			}
+			opts.ExcludeCommits = tips
+		} else {
+			opts.BaseHash = update.OldHash
+		}
+
+		logger.V(1).Info("scanning pushed commits", "commits", len(commits), "head", update.NewHash)
+		if err := g.ScanCommits(ctx, repo, repoPath, opts, reporter); err != nil {
+			return fmt.Errorf("unable to scan pushed commits of %s: %w", update.Ref, err)
+		}
+	}
+	return nil
+}
+
+// refTips returns the commits the existing refs point to. The refs of a
+// pre-receive hook are not updated yet, so the pushed commits are reachable
+// from none of them.
+func refTips(ctx context.Context, repoPath string) ([]string, error) {
+	out, err := runGit(ctx, repoPath, "for-each-ref", "--format=%(objectname)")
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(out), nil
+}
+
+// pushedCommits returns the commits a ref update introduces, oldest first.
+// The refs of a pre-receive hook are not updated yet, so for a created ref
+// the commits not reachable from any ref are exactly the pushed ones.
+func pushedCommits(ctx context.Context, repoPath string, update RefUpdate) ([]string, error) {
+	args := []string{"rev-list", "--reverse", "--topo-order"}
+	if update.IsCreate() {
+		args = append(args, update.NewHash, "--not", "--all")
+	} else {
+		args = append(args, update.OldHash+".."+update.NewHash)
+	}
+	out, err := runGit(ctx, repoPath, args...)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(out), nil
+}
+
+func isBareRepository(ctx context.Context, repoPath string) (bool, error) {
+	out, err := runGit(ctx, repoPath, "rev-parse", "--is-bare-repository")
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(out) == "true", nil
+}
+
+func runGit(ctx context.Context, repoPath string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", repoPath}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
@@ -0,0 +1,168 @@
+package hook
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+)
+
+func TestParseRefUpdates(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []RefUpdate
+		wantErr bool
+	}{
+		{
+			name:  "update and create",
+			input: "aaa bbb refs/heads/main\n\n" + zeroHash + " ccc refs/heads/feature\n",
+			want: []RefUpdate{
+				{OldHash: "aaa", NewHash: "bbb", Ref: "refs/heads/main"},
+				{OldHash: zeroHash, NewHash: "ccc", Ref: "refs/heads/feature"},
+			},
+		},
+		{
+			name:  "empty",
+			input: "",
+		},
+		{
+			name:    "malformed",
+			input:   "aaa refs/heads/main\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRefUpdates(strings.NewReader(tt.input))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	update := RefUpdate{OldHash: zeroHash, NewHash: "ccc"}
+	assert.True(t, update.IsCreate())
+	assert.False(t, update.IsDelete())
+}
+
+// testRepo creates a repository whose commits are dated with the returned
+// commit function, so tests can create history that is out of date order.
+func testRepo(t *testing.T) (dir string, git func(args ...string) string, commit func(msg, date string) string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir = t.TempDir()
+	run := func(env []string, args ...string) string {
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Env = append(os.Environ(), env...)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	git = func(args ...string) string { return run(nil, args...) }
+	commit = func(msg, date string) string {
+		run([]string{"GIT_AUTHOR_DATE=" + date, "GIT_COMMITTER_DATE=" + date}, "commit", "-q", "--allow-empty", "-m", msg)
+		return git("rev-parse", "HEAD")
+	}
+	git("init", "-q", "-b", "main")
+	return dir, git, commit
+}
+
+func TestPushedCommits(t *testing.T) {
+	ctx := context.Background()
+	dir, git, commit := testRepo(t)
+
+	first := commit("first", "2021-01-01T00:00:00Z")
+	second := commit("second", "2021-01-02T00:00:00Z")
+	third := commit("third", "2021-01-03T00:00:00Z")
+	// Move the branch back so the later commits are not reachable from any
+	// ref, like the commits of a push seen from a pre-receive hook.
+	git("reset", "-q", "--hard", first)
+
+	commits, err := pushedCommits(ctx, dir, RefUpdate{OldHash: zeroHash, NewHash: third})
+	require.NoError(t, err)
+	assert.Equal(t, []string{second, third}, commits)
+
+	commits, err = pushedCommits(ctx, dir, RefUpdate{OldHash: zeroHash, NewHash: first})
+	require.NoError(t, err)
+	assert.Empty(t, commits)
+
+	commits, err = pushedCommits(ctx, dir, RefUpdate{OldHash: first, NewHash: third})
+	require.NoError(t, err)
+	assert.Equal(t, []string{second, third}, commits)
+
+	// A created ref is scanned down to the commits the existing refs reach.
+	tips, err := refTips(ctx, dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{first}, tips)
+}
+
+func TestPushedCommitsMergeOfOlderCommits(t *testing.T) {
+	ctx := context.Background()
+	dir, git, commit := testRepo(t)
+
+	base := commit("base", "2020-01-01T00:00:00Z")
+	git("checkout", "-q", "-b", "feature")
+	// The feature commit is dated before the tip of main it is merged into,
+	// so a date ordered log walk from the merge reaches the old tip first.
+	feature := commit("feature", "2020-06-01T00:00:00Z")
+	git("checkout", "-q", "main")
+	oldTip := commit("main", "2021-01-01T00:00:00Z")
+	git("-c", "core.editor=true", "merge", "-q", "--no-ff", "--no-edit", "feature")
+	merge := git("rev-parse", "HEAD")
+	// Leave only the state before the push reachable from refs.
+	git("reset", "-q", "--hard", oldTip)
+	git("branch", "-q", "-D", "feature")
+
+	commits, err := pushedCommits(ctx, dir, RefUpdate{OldHash: oldTip, NewHash: merge, Ref: "refs/heads/main"})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{feature, merge}, commits)
+	assert.NotContains(t, commits, base)
+
+	// A created ref bringing in two new lines of history.
+	commits, err = pushedCommits(ctx, dir, RefUpdate{OldHash: zeroHash, NewHash: merge, Ref: "refs/heads/other"})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{feature, merge}, commits)
+}
+
+func TestFindingsBlock(t *testing.T) {
+	ctx := context.Background()
+	result := func(verified bool) *detectors.ResultWithMetadata {
+		return &detectors.ResultWithMetadata{
+			SourceMetadata: &source_metadatapb.MetaData{
+				Data: &source_metadatapb.MetaData_Git{
+					Git: &source_metadatapb.Git{File: "config.yaml", Line: 4, Commit: "abc123"},
+				},
+			},
+			Result: detectors.Result{Verified: verified, Raw: []byte("hunter2"), Redacted: "hun***"},
+		}
+	}
+
+	f := NewFindings(true)
+	require.NoError(t, f.Print(ctx, result(false)))
+	var b strings.Builder
+	assert.False(t, f.Block(&b))
+	assert.Empty(t, b.String())
+
+	require.NoError(t, f.Print(ctx, result(true)))
+	assert.True(t, f.Block(&b))
+	msg := b.String()
+	assert.Contains(t, msg, "(verified)")
+	assert.Contains(t, msg, "config.yaml:4")
+	assert.Contains(t, msg, "commit: abc123")
+	assert.Contains(t, msg, "1 secret(s) found")
+	assert.NotContains(t, msg, "hunter2")
+}
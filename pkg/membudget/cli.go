@@ -0,0 +1,23 @@
+package membudget
+
+import (
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/alecthomas/units"
+)
+
+var limit *units.Base2Bytes
+
+// Flags registers the memory budget flag on app.
+func Flags(app *kingpin.Application) {
+	limit = app.Flag("memory-budget", "Maximum memory held by chunks and decoded data in flight (e.g. 1GB). Sources wait while it is used up. 0 means no limit.").Default("0").Bytes()
+}
+
+// FromFlags returns the budget given on the command line, or nil if no
+// budget is set.
+func FromFlags() *Budget {
+	// TODO: The engine does not call ChunkDone yet. Until it does, every
+	// reservation is kept for the rest of the scan and the sources block
+	// once the budget is used up, so the engine must not pass this budget
+	// to the sources before it calls ChunkDone for every chunk it scanned.
+	return New(int64(*limit))
+}
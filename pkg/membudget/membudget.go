@@ -0,0 +1,105 @@
+// Package membudget bounds the memory held by in-flight chunks and decoded
+// data. Once the budget is used up, sources are blocked until the engine has
+// scanned enough chunks to free it again.
+package membudget
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/sync/semaphore"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// Budget is a global memory budget. A nil *Budget is unlimited, so callers
+// do not need to check whether a budget was configured.
+type Budget struct {
+	limit int64
+	sem   *semaphore.Weighted
+
+	inUse atomic.Int64
+	waits atomic.Uint64
+
+	// reserved holds the size reserved for each chunk sent by a
+	// ChanReporter until ChunkDone releases it.
+	reservedMu sync.Mutex
+	reserved   map[*sources.Chunk]int64
+}
+
+// New creates a budget of limit bytes. It returns nil, an unlimited budget,
+// if limit is not positive.
+func New(limit int64) *Budget {
+	if limit <= 0 {
+		return nil
+	}
+	return &Budget{limit: limit, sem: semaphore.NewWeighted(limit)}
+}
+
+// weight clamps n to the budget, so that a single allocation larger than the
+// whole budget waits for everything else to be released instead of blocking
+// forever.
+func (b *Budget) weight(n int64) int64 {
+	return max(min(n, b.limit), 0)
+}
+
+// Acquire reserves n bytes, blocking until they are available or ctx is
+// done.
+func (b *Budget) Acquire(ctx context.Context, n int64) error {
+	if b == nil {
+		return nil
+	}
+	w := b.weight(n)
+	if !b.sem.TryAcquire(w) {
+		b.waits.Add(1)
+		if err := b.sem.Acquire(ctx, w); err != nil {
+			return err
+		}
+	}
+	b.inUse.Add(w)
+	return nil
+}
+
+// TryAcquire reserves n bytes if they are available without blocking.
+func (b *Budget) TryAcquire(n int64) bool {
+	if b == nil {
+		return true
+	}
+	w := b.weight(n)
+	if !b.sem.TryAcquire(w) {
+		return false
+	}
+	b.inUse.Add(w)
+	return true
+}
+
+// Release returns n bytes reserved by Acquire or TryAcquire to the budget.
+func (b *Budget) Release(n int64) {
+	if b == nil {
+		return
+	}
+	w := b.weight(n)
+	b.inUse.Add(-w)
+	b.sem.Release(w)
+}
+
+// Stats is a snapshot of the use of a Budget.
+type Stats struct {
+	Limit int64
+	InUse int64
+	// Waits is how often an Acquire had to wait for memory to be released.
+	Waits uint64
+}
+
+// Stats returns the current use of the budget.
+func (b *Budget) Stats() Stats {
+	if b == nil {
+		return Stats{}
+	}
+	return Stats{
+		Limit: b.limit,
+		InUse: b.inUse.Load(),
+		Waits: b.waits.Load(),
+	}
+}
@@ -0,0 +1,96 @@
+package membudget
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+func TestBudget(t *testing.T) {
+	ctx := context.Background()
+	b := New(100)
+
+	require.NoError(t, b.Acquire(ctx, 60))
+	assert.False(t, b.TryAcquire(50))
+	// Allocations larger than the budget wait for all of it.
+	acquired := make(chan struct{})
+	go func() {
+		assert.NoError(t, b.Acquire(ctx, 1000))
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("acquired memory that is in use")
+	case <-time.After(20 * time.Millisecond):
+	}
+	b.Release(60)
+	select {
+	case <-acquired:
+	case <-time.After(5 * time.Second):
+		t.Fatal("released memory was not handed out")
+	}
+	assert.Equal(t, Stats{Limit: 100, InUse: 100, Waits: 1}, b.Stats())
+	b.Release(1000)
+	assert.Zero(t, b.Stats().InUse)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	require.NoError(t, b.Acquire(ctx, 100))
+	assert.Error(t, b.Acquire(cancelled, 1))
+}
+
+func TestNilBudget(t *testing.T) {
+	var b *Budget
+	assert.Nil(t, New(0))
+	assert.NoError(t, b.Acquire(context.Background(), 1<<40))
+	assert.True(t, b.TryAcquire(1<<40))
+	b.Release(1 << 40)
+	assert.Equal(t, Stats{}, b.Stats())
+}
+
+func TestChanReporterBackpressure(t *testing.T) {
+	ctx := context.Background()
+	b := New(10)
+	ch := make(chan *sources.Chunk, 10)
+	reporter := b.ChanReporter(ch)
+
+	require.NoError(t, reporter.ChunkOk(ctx, sources.Chunk{Data: []byte("12345678")}))
+	reported := make(chan struct{})
+	go func() {
+		assert.NoError(t, reporter.ChunkOk(ctx, sources.Chunk{Data: []byte("12345")}))
+		close(reported)
+	}()
+
+	select {
+	case <-reported:
+		t.Fatal("chunk was reported beyond the budget")
+	case <-time.After(20 * time.Millisecond):
+	}
+	first := <-ch
+	// Decoders replace the data; the reservation is still released in full.
+	first.Data = []byte("decoded data that is longer than the original")
+	b.ChunkDone(first)
+	<-reported
+	second := <-ch
+	assert.Equal(t, "12345", string(second.Data))
+	second.Data = nil
+	b.ChunkDone(second)
+	assert.Zero(t, b.Stats().InUse)
+
+	// Releasing twice, or a chunk that was never reserved, does nothing.
+	b.ChunkDone(second)
+	b.ChunkDone(&sources.Chunk{Data: []byte("12345")})
+	assert.Zero(t, b.Stats().InUse)
+
+	// Without a budget, chunks are passed on unchanged.
+	var unlimited *Budget
+	require.NoError(t, unlimited.ChanReporter(ch).ChunkOk(ctx, sources.Chunk{Data: []byte("x")}))
+	assert.Equal(t, "x", string((<-ch).Data))
+	unlimited.ChunkDone(second)
+}
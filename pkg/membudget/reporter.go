@@ -0,0 +1,79 @@
+package membudget
+
+import (
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// Budgeted is implemented by sources that reserve memory for the chunks they
+// send. WithMemoryBudget must be called before the source produces chunks.
+type Budgeted interface {
+	WithMemoryBudget(b *Budget)
+}
+
+// ChanReporter returns a ChunkReporter that reserves the size of every chunk
+// and then sends it to ch, like sources.ChanReporter. While the budget is used
+// up, ChunkOk blocks, which stops the reporting source until memory is
+// released. The consumer of ch must call ChunkDone with each chunk it
+// received once it is done with it and with everything decoded from it.
+//
+// The reservation is remembered for the chunk pointer sent on ch, not
+// derived from the chunk's data, because decoders replace the data with
+// their output.
+func (b *Budget) ChanReporter(ch chan<- *sources.Chunk) sources.ChunkReporter {
+	if b == nil {
+		return sources.ChanReporter{Ch: ch}
+	}
+	return &chanReporter{budget: b, ch: ch}
+}
+
+// ChunkDone releases the memory reserved for a chunk sent by a ChanReporter.
+// It does nothing for chunks without a reservation, so it is safe to call for
+// every chunk and more than once.
+func (b *Budget) ChunkDone(chunk *sources.Chunk) {
+	if b == nil {
+		return
+	}
+	b.reservedMu.Lock()
+	size, ok := b.reserved[chunk]
+	delete(b.reserved, chunk)
+	b.reservedMu.Unlock()
+	if ok {
+		b.Release(size)
+	}
+}
+
+// reserve records the reservation of chunk.
+func (b *Budget) reserve(chunk *sources.Chunk, size int64) {
+	b.reservedMu.Lock()
+	defer b.reservedMu.Unlock()
+	if b.reserved == nil {
+		b.reserved = make(map[*sources.Chunk]int64)
+	}
+	b.reserved[chunk] = size
+}
+
+type chanReporter struct {
+	budget *Budget
+	ch     chan<- *sources.Chunk
+}
+
+func (r *chanReporter) ChunkOk(ctx context.Context, chunk sources.Chunk) error {
+	size := int64(len(chunk.Data))
+	if err := r.budget.Acquire(ctx, size); err != nil {
+		return err
+	}
+	c := &chunk
+	r.budget.reserve(c, size)
+	select {
+	case r.ch <- c:
+		return nil
+	case <-ctx.Done():
+		r.budget.ChunkDone(c)
+		return ctx.Err()
+	}
+}
+
+func (r *chanReporter) ChunkErr(ctx context.Context, err error) error {
+	return sources.ChanReporter{Ch: r.ch}.ChunkErr(ctx, err)
+}
@@ -0,0 +1,148 @@
+package output
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+)
+
+const (
+	// redactedSecret replaces the secret in context lines.
+	redactedSecret = "[REDACTED]"
+	// maxContextLineLength caps the length of a context line, so that a
+	// finding in minified or generated files does not embed the whole file.
+	maxContextLineLength = 240
+)
+
+// FindingContext holds the lines surrounding a finding. The secret itself, and
+// anything else in the lines that looks like a credential, is replaced with
+// "[REDACTED]" so the context can be shared safely.
+type FindingContext struct {
+	// StartLine is the line number of the first line, or 0 if the source
+	// does not report line numbers.
+	StartLine int64 `json:"start_line,omitempty"`
+	// MatchIndex is the index in Lines of the line containing the secret.
+	MatchIndex int      `json:"match_index"`
+	Lines      []string `json:"lines"`
+}
+
+// EndLine returns the line number of the last line, or 0 if unknown.
+func (c *FindingContext) EndLine() int64 {
+	if c.StartLine == 0 {
+		return 0
+	}
+	return c.StartLine + int64(len(c.Lines)) - 1
+}
+
+// String returns the context lines joined by newlines.
+func (c *FindingContext) String() string { return strings.Join(c.Lines, "\n") }
+
+// ContextOf returns up to n lines before and after the secret of r, taken from
+// the chunk data the result was found in. It returns nil if n is not
+// positive or the secret cannot be located in the chunk, for example because
+// it was found in decoded data.
+func ContextOf(r *detectors.ResultWithMetadata, loc ResultLocation, n int) *FindingContext {
+	if n <= 0 || len(r.Raw) == 0 {
+		return nil
+	}
+	offset := bytes.Index(r.Data, r.Raw)
+	if offset < 0 {
+		return nil
+	}
+
+	lines := strings.Split(string(r.Data), "\n")
+	match := bytes.Count(r.Data[:offset], []byte("\n"))
+	first, last := max(match-n, 0), min(match+n, len(lines)-1)
+
+	c := &FindingContext{MatchIndex: match - first}
+	if loc.Line > 0 {
+		// The reported line is the line of the secret.
+		c.StartLine = max(loc.Line-int64(match-first), 1)
+	}
+	for _, line := range lines[first : last+1] {
+		line = strings.TrimSuffix(line, "\r")
+		c.Lines = append(c.Lines, truncateContextLine(redactSecret(line, r)))
+	}
+	return c
+}
+
+// credentialToken matches the runs of characters that keys, tokens and
+// passwords are made of. Those that look like credentials are masked.
+var credentialToken = regexp.MustCompile(`[A-Za-z0-9+/=_\-]{16,}`)
+
+// credentialAssignment matches the value assigned to a key whose name suggests
+// a credential, as in "password = hunter2", "DB_PASS=correcthorse" or
+// `"api_key": "..."`. Such values are masked however short or plain they are.
+// Comparisons such as "token == nil" are not assignments.
+var credentialAssignment = regexp.MustCompile(`(?i)(\b[\w.\-]*(?:pass|pwd|secret|token|key)[\w.\-]*["']?\s*(?::=|[:=])\s*)("[^"]*"|'[^']*'|[^\s"',;=][^\s"',;]*)`)
+
+// redactSecret replaces all forms of the secret of r in line, then masks the
+// values of credential assignments and every other token that looks like a
+// credential. The secret is not the only
+// one a context window can expose: the second half of a multi-part
+// credential such as an AWS secret key is not part of Raw and only appears
+// concatenated in RawV2, and neighbouring lines can hold unrelated secrets.
+func redactSecret(line string, r *detectors.ResultWithMetadata) string {
+	// Replace the longer form first so that it is not left partially intact.
+	secrets := []string{string(r.RawV2), string(r.Raw)}
+	if len(secrets[1]) > len(secrets[0]) {
+		secrets[0], secrets[1] = secrets[1], secrets[0]
+	}
+	for _, s := range secrets {
+		if s != "" {
+			line = strings.ReplaceAll(line, s, redactedSecret)
+		}
+	}
+	line = credentialAssignment.ReplaceAllString(line, "${1}"+redactedSecret)
+	return credentialToken.ReplaceAllStringFunc(line, func(token string) string {
+		if looksLikeCredential(token) {
+			return redactedSecret
+		}
+		return token
+	})
+}
+
+// looksLikeCredential reports whether a long token mixes letters and digits,
+// which identifiers and words rarely do but generated secrets almost always
+// do.
+func looksLikeCredential(token string) bool {
+	var letter, digit bool
+	for _, c := range token {
+		letter = letter || unicode.IsLetter(c)
+		digit = digit || unicode.IsDigit(c)
+	}
+	return letter && digit
+}
+
+// truncateContextLine shortens long lines, keeping the redacted secret in
+// view when it is present.
+func truncateContextLine(line string) string {
+	if len(line) <= maxContextLineLength {
+		return line
+	}
+	start := 0
+	if i := strings.Index(line, redactedSecret); i > maxContextLineLength/2 {
+		start = i - maxContextLineLength/2
+	}
+	end := min(start+maxContextLineLength, len(line))
+	// Do not split multi-byte characters.
+	for start > 0 && !utf8.RuneStart(line[start]) {
+		start--
+	}
+	for end < len(line) && !utf8.RuneStart(line[end]) {
+		end--
+	}
+
+	out := line[start:end]
+	if start > 0 {
+		out = "…" + out
+	}
+	if end < len(line) {
+		out += "…"
+	}
+	return out
+}
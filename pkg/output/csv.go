@@ -0,0 +1,187 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+)
+
+// csvColumns are the columns written by the CSVPrinter. The order is part of
+// the output format and must not change; new columns go at the end.
+var csvColumns = []string{
+	"detector_type",
+	"decoder_type",
+	"verified",
+	"verification_error",
+	"redacted",
+	"source_type",
+	"source_name",
+	"file",
+	"line",
+	"commit",
+	"email",
+	"link",
+	"extra_data",
+	"fingerprint",
+	"product",
+	"rotation_url",
+	"revocation_endpoint",
+	"docs_url",
+	"column",
+	"offset",
+}
+
+// CSVPrinter is a printer that writes one row per result with a fixed set of
+// columns. It can produce both CSV and TSV output.
+type CSVPrinter struct {
+	mu            sync.Mutex
+	w             *csv.Writer
+	headerWritten bool
+}
+
+// NewCSVPrinter creates a CSVPrinter that writes comma separated rows to w. If
+// w is nil, rows are written to stdout.
+func NewCSVPrinter(w io.Writer) *CSVPrinter {
+	return newDelimitedPrinter(w, ',')
+}
+
+// NewTSVPrinter creates a CSVPrinter that writes tab separated rows to w. If w
+// is nil, rows are written to stdout.
+func NewTSVPrinter(w io.Writer) *CSVPrinter {
+	return newDelimitedPrinter(w, '\t')
+}
+
+func newDelimitedPrinter(w io.Writer, comma rune) *CSVPrinter {
+	if w == nil {
+		w = os.Stdout
+	}
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	return &CSVPrinter{w: cw}
+}
+
+func (p *CSVPrinter) Print(_ context.Context, r *detectors.ResultWithMetadata) error {
+	loc, err := LocationOf(r)
+	if err != nil {
+		return fmt.Errorf("could not marshal result: %w", err)
+	}
+
+	var verificationErr string
+	if err := r.VerificationError(); err != nil {
+		verificationErr = err.Error()
+	}
+	var line, column, offset string
+	if loc.Line > 0 {
+		line = strconv.FormatInt(loc.Line, 10)
+	}
+	if loc.Column > 0 {
+		column = strconv.FormatInt(loc.Column, 10)
+		offset = strconv.FormatInt(loc.Offset, 10)
+	}
+
+	var rem detectors.Remediation
+	if found := remediationOf(r); found != nil {
+		rem = *found
+	}
+
+	extraData, err := flattenExtraData(r.ExtraData)
+	if err != nil {
+		return fmt.Errorf("could not marshal extra data: %w", err)
+	}
+
+	row := []string{
+		r.DetectorType.String(),
+		r.DecoderType.String(),
+		strconv.FormatBool(r.Verified),
+		verificationErr,
+		r.Redacted,
+		r.SourceType.String(),
+		r.SourceName,
+		loc.File,
+		line,
+		loc.Commit,
+		loc.Email,
+		loc.Link,
+		extraData,
+		fingerprintOf(r, loc),
+		rem.Product,
+		rem.RotationURL,
+		rem.RevocationEndpoint,
+		rem.DocsURL,
+		column,
+		offset,
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.writeHeader(); err != nil {
+		return err
+	}
+	for i := range row {
+		row[i] = escapeFormula(row[i])
+	}
+	if err := p.w.Write(row); err != nil {
+		return fmt.Errorf("could not write row: %w", err)
+	}
+	p.w.Flush()
+	return p.w.Error()
+}
+
+// Close writes the header if no result was printed, so that consumers always
+// receive a well formed table.
+func (p *CSVPrinter) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.writeHeader(); err != nil {
+		return err
+	}
+	p.w.Flush()
+	return p.w.Error()
+}
+
+func (p *CSVPrinter) writeHeader() error {
+	if p.headerWritten {
+		return nil
+	}
+	if err := p.w.Write(csvColumns); err != nil {
+		return fmt.Errorf("could not write header: %w", err)
+	}
+	p.headerWritten = true
+	return nil
+}
+
+// flattenExtraData renders extra data as a JSON object with sorted keys, so
+// that values containing separators stay unambiguous.
+func flattenExtraData(extra map[string]string) (string, error) {
+	if len(extra) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(extra)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// escapeFormula prefixes cells that spreadsheet applications would evaluate
+// as a formula with a single quote. Cells hold data taken from the scanned
+// content, such as file names and commit authors, which an attacker controls.
+func escapeFormula(cell string) string {
+	if cell == "" {
+		return cell
+	}
+	switch cell[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return "'" + cell
+	}
+	return cell
+}
@@ -0,0 +1,119 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/output/outputtest"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+)
+
+func TestCSVPrinter(t *testing.T) {
+	ctx := context.Background()
+	r := outputtest.GitResult("AKIACSV", "https://github.com/org/repo.git", "=HYPERLINK(\"http://evil\")", "abc123", 4, true)
+	r.Redacted = "AKIA****"
+	r.ExtraData = map[string]string{
+		"account": "a; b=c",
+		"arn":     "arn:aws:iam::1:user/x",
+	}
+
+	tests := []struct {
+		name  string
+		new   func(*bytes.Buffer) *CSVPrinter
+		comma rune
+	}{
+		{name: "csv", new: func(b *bytes.Buffer) *CSVPrinter { return NewCSVPrinter(b) }, comma: ','},
+		{name: "tsv", new: func(b *bytes.Buffer) *CSVPrinter { return NewTSVPrinter(b) }, comma: '\t'},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			p := tt.new(&out)
+			require.NoError(t, p.Print(ctx, r))
+			require.NoError(t, p.Close())
+
+			reader := csv.NewReader(&out)
+			reader.Comma = tt.comma
+			rows, err := reader.ReadAll()
+			require.NoError(t, err)
+			require.Len(t, rows, 2)
+			assert.Equal(t, csvColumns, rows[0])
+
+			row := make(map[string]string, len(csvColumns))
+			for i, col := range csvColumns {
+				row[col] = rows[1][i]
+			}
+			assert.Equal(t, "true", row["verified"])
+			assert.Equal(t, "AKIA****", row["redacted"])
+			assert.Equal(t, "4", row["line"])
+			assert.Equal(t, "abc123", row["commit"])
+			assert.Equal(t, `'=HYPERLINK("http://evil")`, row["file"], "formulas must be escaped")
+
+			var extra map[string]string
+			require.NoError(t, json.Unmarshal([]byte(row["extra_data"]), &extra))
+			assert.Equal(t, r.ExtraData, extra)
+
+			fingerprint, err := Fingerprint(r)
+			require.NoError(t, err)
+			assert.Equal(t, fingerprint, row["fingerprint"])
+			assert.NotContains(t, out.String(), "AKIACSV", "rows must not contain raw secrets")
+		})
+	}
+}
+
+func TestCSVPrinter_Position(t *testing.T) {
+	r := &detectors.ResultWithMetadata{
+		SourceMetadata: &source_metadatapb.MetaData{
+			Data: &source_metadatapb.MetaData_Jenkins{Jenkins: &source_metadatapb.Jenkins{ProjectName: "deploy", BuildNumber: 7}},
+		},
+		Result: detectors.Result{Raw: []byte("secret123")},
+		Data:   []byte("Started\n+ export TOKEN=secret123\n"),
+	}
+
+	var out bytes.Buffer
+	p := NewCSVPrinter(&out)
+	require.NoError(t, p.Print(context.Background(), r))
+	require.NoError(t, p.Close())
+
+	rows, err := csv.NewReader(&out).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	row := make(map[string]string, len(csvColumns))
+	for i, col := range csvColumns {
+		row[col] = rows[1][i]
+	}
+	assert.Equal(t, "2", row["line"])
+	assert.Equal(t, "16", row["column"])
+	assert.Equal(t, "23", row["offset"])
+}
+
+func TestCSVPrinter_Empty(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, NewCSVPrinter(&out).Close())
+
+	rows, err := csv.NewReader(&out).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{csvColumns}, rows)
+}
+
+func TestEscapeFormula(t *testing.T) {
+	for in, want := range map[string]string{
+		"":           "",
+		"plain":      "plain",
+		"=1+1":       "'=1+1",
+		"+1":         "'+1",
+		"-1":         "'-1",
+		"@SUM(A1)":   "'@SUM(A1)",
+		"\tindented": "'\tindented",
+		"a=b":        "a=b",
+	} {
+		assert.Equal(t, want, escapeFormula(in), in)
+	}
+}
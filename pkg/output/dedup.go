@@ -0,0 +1,149 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+)
+
+// DedupPrinter is a printer that groups identical secrets, i.e. results of
+// the same detector with the same Raw and RawV2, across all sources. When
+// closed, it writes one JSON line per secret listing every location the
+// secret was found in, so a token copied into many commits is reported once.
+type DedupPrinter struct {
+	mu sync.Mutex
+	w  io.Writer
+
+	index    map[string]int
+	findings []*dedupFinding
+}
+
+// NewDedupPrinter creates a DedupPrinter that writes the grouped findings to
+// w. If w is nil, they are written to stdout.
+func NewDedupPrinter(w io.Writer) *DedupPrinter {
+	if w == nil {
+		w = os.Stdout
+	}
+	return &DedupPrinter{w: w, index: make(map[string]int)}
+}
+
+func (p *DedupPrinter) Print(_ context.Context, r *detectors.ResultWithMetadata) error {
+	loc, err := LocationOf(r)
+	if err != nil {
+		return fmt.Errorf("could not marshal result: %w", err)
+	}
+	secretHash := SecretHash(r)
+	key := r.DetectorType.String() + "\x00" + r.DetectorName + "\x00" + secretHash
+
+	occurrence := dedupLocation{
+		Fingerprint: fingerprintOf(r, loc),
+		SourceType:  r.SourceType.String(),
+		SourceName:  r.SourceName,
+		Repository:  NormalizeRepository(loc.Repository),
+		File:        loc.File,
+		Line:        loc.Line,
+		Commit:      loc.Commit,
+		Email:       loc.Email,
+		Link:        loc.Link,
+		Timestamp:   loc.Timestamp,
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	idx, ok := p.index[key]
+	if !ok {
+		idx = len(p.findings)
+		p.index[key] = idx
+		p.findings = append(p.findings, &dedupFinding{
+			DetectorType: r.DetectorType.String(),
+			DetectorName: r.DetectorName,
+			SecretHash:   secretHash,
+			Redacted:     r.Redacted,
+			Status:       VerificationStatus(r),
+			Remediation:  remediationOf(r),
+			seen:         make(map[string]struct{}),
+		})
+	}
+	f := p.findings[idx]
+
+	// A secret is as live as its most live occurrence: verification may fail
+	// transiently for some copies and succeed for others.
+	switch {
+	case r.Verified:
+		f.Verified = true
+		f.Status = "verified"
+		f.VerificationError = ""
+	case r.VerificationError() != nil && !f.Verified:
+		f.Status = "unknown"
+		f.VerificationError = r.VerificationError().Error()
+	}
+
+	// The same occurrence can be reported more than once, e.g. by
+	// overlapping chunks.
+	if _, dup := f.seen[occurrence.Fingerprint]; !dup {
+		f.seen[occurrence.Fingerprint] = struct{}{}
+		f.Locations = append(f.Locations, occurrence)
+		f.Count++
+	}
+	return nil
+}
+
+// Close writes the grouped findings, verified secrets and those with the most
+// locations first. It must be called once after the scan has finished.
+func (p *DedupPrinter) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	sort.SliceStable(p.findings, func(i, j int) bool {
+		a, b := p.findings[i], p.findings[j]
+		if a.Verified != b.Verified {
+			return a.Verified
+		}
+		return a.Count > b.Count
+	})
+
+	enc := json.NewEncoder(p.w)
+	for _, f := range p.findings {
+		if err := enc.Encode(f); err != nil {
+			return fmt.Errorf("could not write deduplicated finding: %w", err)
+		}
+	}
+	return nil
+}
+
+type dedupFinding struct {
+	DetectorType      string          `json:"detector_type"`
+	DetectorName      string          `json:"detector_name"`
+	SecretHash        string          `json:"secret_hash"`
+	Redacted          string          `json:"redacted,omitempty"`
+	Verified          bool            `json:"verified"`
+	Status            string          `json:"status"`
+	VerificationError string          `json:"verification_error,omitempty"`
+	Count             int             `json:"count"`
+	Locations         []dedupLocation `json:"locations"`
+	// Remediation is shared by all locations, since they hold the same
+	// secret.
+	Remediation *detectors.Remediation `json:"remediation,omitempty"`
+
+	seen map[string]struct{}
+}
+
+type dedupLocation struct {
+	Fingerprint string `json:"fingerprint"`
+	SourceType  string `json:"source_type"`
+	SourceName  string `json:"source_name"`
+	Repository  string `json:"repository,omitempty"`
+	File        string `json:"file,omitempty"`
+	Line        int64  `json:"line,omitempty"`
+	Commit      string `json:"commit,omitempty"`
+	Email       string `json:"email,omitempty"`
+	Link        string `json:"link,omitempty"`
+	Timestamp   string `json:"timestamp,omitempty"`
+}
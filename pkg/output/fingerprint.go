@@ -0,0 +1,83 @@
+package output
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+)
+
+// fingerprintVersion is mixed into every fingerprint. Bump it if the inputs or
+// their normalization change, so old and new fingerprints never collide.
+const fingerprintVersion = "1"
+
+// Fingerprint returns a deterministic identifier for a result that survives
+// re-runs of the same scan. It is derived from the detector type, a hash of
+// the secret and the normalized location of the finding. The verification
+// status is deliberately not part of the fingerprint, so a secret keeps its
+// identity when it is rotated or revoked.
+func Fingerprint(r *detectors.ResultWithMetadata) (string, error) {
+	loc, err := LocationOf(r)
+	if err != nil {
+		return "", err
+	}
+	return fingerprintOf(r, loc), nil
+}
+
+func fingerprintOf(r *detectors.ResultWithMetadata, loc ResultLocation) string {
+	line := loc.Line
+	if loc.computed {
+		line = 0
+	}
+	fields := []string{
+		fingerprintVersion,
+		r.DetectorType.String(),
+		r.DetectorName,
+		SecretHash(r),
+		r.SourceType.String(),
+		NormalizeRepository(loc.Repository),
+		NormalizePath(loc.File),
+		strconv.FormatInt(line, 10),
+		strings.ToLower(loc.Commit),
+	}
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// SecretHash returns the hex encoded SHA-256 of the secret of a result. It
+// allows matching a secret without storing it.
+func SecretHash(r *detectors.ResultWithMetadata) string {
+	h := sha256.New()
+	h.Write(r.Raw)
+	h.Write([]byte{0})
+	h.Write(r.RawV2)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// NormalizeRepository strips credentials, a trailing ".git" and trailing
+// slashes from a repository URL so that different clone URLs of the same
+// repository produce the same fingerprint.
+func NormalizeRepository(repo string) string {
+	repo = strings.TrimSpace(repo)
+	if u, err := url.Parse(repo); err == nil && u.Host != "" {
+		u.User = nil
+		u.Host = strings.ToLower(u.Host)
+		repo = u.String()
+	}
+	repo = strings.TrimRight(repo, "/")
+	return strings.TrimSuffix(repo, ".git")
+}
+
+// NormalizePath cleans a file path and uses forward slashes, so that "./a/b"
+// and "a//b" fingerprint the same.
+func NormalizePath(p string) string {
+	if p == "" {
+		return ""
+	}
+	p = path.Clean(strings.ReplaceAll(p, "\\", "/"))
+	return strings.TrimPrefix(p, "./")
+}
@@ -1 +1,81 @@
+package output
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+)
+
+var dedupeCache = make(map[string]struct{})
+
+// GitHubActionsPrinter is a printer that prints results in GitHub Actions format.
+type GitHubActionsPrinter struct {
+	mu sync.Mutex
+	// out is where results are written. It defaults to stdout.
+	out io.Writer
+}
+
+func (p *GitHubActionsPrinter) Print(_ context.Context, r *detectors.ResultWithMetadata) error {
+	out := gitHubActionsOutputFormat{
+		DetectorType: r.Result.DetectorType.String(),
+		DecoderType:  r.DecoderType.String(),
+		Verified:     r.Result.Verified,
+	}
+
+	loc, err := LocationOf(r)
+	if err != nil {
+		return fmt.Errorf("could not marshal result: %w", err)
+	}
+	out.StartLine = loc.Line
+	out.Filename = loc.File
+	out.Fingerprint = fingerprintOf(r, loc)
+
+	verifiedStatus := "unverified"
+	if out.Verified {
+		verifiedStatus = "verified"
+	}
+
+	key := fmt.Sprintf("%s:%s:%s:%s:%d", out.DecoderType, out.DetectorType, verifiedStatus, out.Filename, out.StartLine)
+	h := sha256.New()
+	h.Write([]byte(key))
+	key = hex.EncodeToString(h.Sum(nil))
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := dedupeCache[key]; ok {
+		return nil
+	}
+	dedupeCache[key] = struct{}{}
+
+	message := fmt.Sprintf("Found %s %s result 🐷🔑", verifiedStatus, out.DetectorType)
+	if r.Result.DecoderType != detectorspb.DecoderType_PLAIN {
+		message = fmt.Sprintf("Found %s %s result with %s encoding 🐷🔑", verifiedStatus, out.DetectorType, out.DecoderType)
+	}
+	if steps := remediationSteps(remediationOf(r)); steps != "" {
+		message += " " + steps
+	}
+
+	w := p.out
+	if w == nil {
+		w = os.Stdout
+	}
+	_, err = fmt.Fprintf(w, "::warning file=%s,line=%d,endLine=%d::%s (fingerprint %s)\n",
+		out.Filename, out.StartLine, out.StartLine, message, out.Fingerprint)
+	return err
+}
+
+type gitHubActionsOutputFormat struct {
+	DetectorType,
+	DecoderType string
+	Verified    bool
+	StartLine   int64
+	Filename    string
+	Fingerprint string
+}
@@ -0,0 +1,224 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/version"
+)
+
+const (
+	gitLabReportVersion = "15.0.7"
+	gitLabTimeFormat    = "2006-01-02T15:04:05"
+	// gitLabNoCommit is the commit SHA of findings outside of git history.
+	// The report schema requires a commit for every location.
+	gitLabNoCommit = "0000000"
+)
+
+// GitLabSecretDetectionPrinter is a printer that collects results and writes
+// them as a GitLab secret detection report (gl-secret-detection-report.json)
+// when closed.
+type GitLabSecretDetectionPrinter struct {
+	mu sync.Mutex
+	w  io.Writer
+
+	startTime       time.Time
+	vulnerabilities []gitLabVulnerability
+}
+
+// NewGitLabSecretDetectionPrinter creates a GitLabSecretDetectionPrinter that
+// writes the report to w. If w is nil, the report is written to stdout.
+func NewGitLabSecretDetectionPrinter(w io.Writer) *GitLabSecretDetectionPrinter {
+	if w == nil {
+		w = os.Stdout
+	}
+	return &GitLabSecretDetectionPrinter{w: w, startTime: time.Now()}
+}
+
+func (p *GitLabSecretDetectionPrinter) Print(_ context.Context, r *detectors.ResultWithMetadata) error {
+	loc, err := LocationOf(r)
+	if err != nil {
+		return fmt.Errorf("could not marshal result: %w", err)
+	}
+
+	detectorName := r.DetectorType.String()
+	status := VerificationStatus(r)
+	description := fmt.Sprintf("TruffleHog found a %s secret. Verification status: %s.", detectorName, status)
+	if err := r.VerificationError(); err != nil {
+		description = fmt.Sprintf("%s Verification failed: %s", description, err)
+	}
+	if r.Redacted != "" {
+		description = fmt.Sprintf("%s Redacted: %s", description, r.Redacted)
+	}
+
+	solution := "Rotate the secret and remove it from the source."
+	rem := remediationOf(r)
+	if steps := remediationSteps(rem); steps != "" {
+		solution = steps + " Then remove it from the source."
+	}
+
+	vuln := gitLabVulnerability{
+		ID:          fingerprintOf(r, loc),
+		Name:        fmt.Sprintf("%s secret", detectorName),
+		Description: description,
+		Severity:    gitLabSeverity(r),
+		Solution:    solution,
+		Identifiers: []gitLabIdentifier{{
+			Type:  "trufflehog_detector",
+			Name:  fmt.Sprintf("TruffleHog %s", detectorName),
+			Value: detectorName,
+		}},
+		Location: gitLabLocation{
+			File:      loc.File,
+			StartLine: loc.Line,
+			EndLine:   loc.Line,
+			Commit:    gitLabCommit{SHA: gitLabNoCommit},
+		},
+	}
+	if loc.Commit != "" {
+		vuln.Location.Commit = gitLabCommit{
+			SHA:    loc.Commit,
+			Author: loc.Email,
+			Date:   loc.Timestamp,
+		}
+	}
+	if loc.Link != "" {
+		vuln.Links = []gitLabLink{{URL: loc.Link}}
+	}
+	if rem != nil {
+		for _, link := range []gitLabLink{
+			{Name: "Rotate " + rem.Product + " secret", URL: rem.RotationURL},
+			{Name: rem.Product + " documentation", URL: rem.DocsURL},
+		} {
+			if link.URL != "" {
+				vuln.Links = append(vuln.Links, link)
+			}
+		}
+	}
+
+	p.mu.Lock()
+	p.vulnerabilities = append(p.vulnerabilities, vuln)
+	p.mu.Unlock()
+
+	return nil
+}
+
+// Close writes the collected results as a GitLab secret detection report. It
+// must be called once after the scan has finished.
+func (p *GitLabSecretDetectionPrinter) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	vulnerabilities := p.vulnerabilities
+	if vulnerabilities == nil {
+		vulnerabilities = []gitLabVulnerability{}
+	}
+
+	tool := gitLabTool{
+		ID:      "trufflehog",
+		Name:    "TruffleHog",
+		URL:     "https://github.com/trufflesecurity/trufflehog",
+		Version: version.BuildVersion,
+		Vendor:  gitLabVendor{Name: "Truffle Security"},
+	}
+	report := gitLabReport{
+		Version:         gitLabReportVersion,
+		Vulnerabilities: vulnerabilities,
+		Scan: gitLabScan{
+			Analyzer:  tool,
+			Scanner:   tool,
+			Type:      "secret_detection",
+			StartTime: p.startTime.UTC().Format(gitLabTimeFormat),
+			EndTime:   time.Now().UTC().Format(gitLabTimeFormat),
+			Status:    "success",
+		},
+	}
+
+	enc := json.NewEncoder(p.w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return fmt.Errorf("could not write GitLab report: %w", err)
+	}
+	return nil
+}
+
+// gitLabSeverity maps the verification status of a result to a GitLab
+// severity. Live credentials are always critical.
+func gitLabSeverity(r *detectors.ResultWithMetadata) string {
+	switch {
+	case r.Verified:
+		return "Critical"
+	case r.VerificationError() != nil:
+		return "High"
+	default:
+		return "Medium"
+	}
+}
+
+type gitLabReport struct {
+	Version         string                `json:"version"`
+	Vulnerabilities []gitLabVulnerability `json:"vulnerabilities"`
+	Scan            gitLabScan            `json:"scan"`
+}
+
+type gitLabVulnerability struct {
+	ID          string             `json:"id"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Severity    string             `json:"severity"`
+	Solution    string             `json:"solution"`
+	Identifiers []gitLabIdentifier `json:"identifiers"`
+	Links       []gitLabLink       `json:"links,omitempty"`
+	Location    gitLabLocation     `json:"location"`
+}
+
+type gitLabIdentifier struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type gitLabLink struct {
+	Name string `json:"name,omitempty"`
+	URL  string `json:"url"`
+}
+
+type gitLabLocation struct {
+	File      string       `json:"file"`
+	StartLine int64        `json:"start_line,omitempty"`
+	EndLine   int64        `json:"end_line,omitempty"`
+	Commit    gitLabCommit `json:"commit"`
+}
+
+type gitLabCommit struct {
+	SHA    string `json:"sha"`
+	Author string `json:"author,omitempty"`
+	Date   string `json:"date,omitempty"`
+}
+
+type gitLabScan struct {
+	Analyzer  gitLabTool `json:"analyzer"`
+	Scanner   gitLabTool `json:"scanner"`
+	Type      string     `json:"type"`
+	StartTime string     `json:"start_time"`
+	EndTime   string     `json:"end_time"`
+	Status    string     `json:"status"`
+}
+
+type gitLabTool struct {
+	ID      string       `json:"id"`
+	Name    string       `json:"name"`
+	URL     string       `json:"url"`
+	Version string       `json:"version"`
+	Vendor  gitLabVendor `json:"vendor"`
+}
+
+type gitLabVendor struct {
+	Name string `json:"name"`
+}
@@ -0,0 +1,129 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/output/outputtest"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+)
+
+// gitLabTimePattern is the pattern the report schema requires for times.
+var gitLabTimePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}$`)
+
+// validateGitLabReport checks report against the constraints of GitLab's
+// secret detection report schema (version 15) that a report can violate.
+func validateGitLabReport(t *testing.T, report []byte) {
+	t.Helper()
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(report, &doc))
+
+	object := func(v any, path string) map[string]any {
+		m, ok := v.(map[string]any)
+		require.True(t, ok, "%s must be an object", path)
+		return m
+	}
+	str := func(m map[string]any, key, path string) string {
+		s, ok := m[key].(string)
+		require.True(t, ok, "%s.%s must be a string", path, key)
+		require.NotEmpty(t, s, "%s.%s must not be empty", path, key)
+		return s
+	}
+	oneOf := func(got, path string, allowed ...string) {
+		assert.Contains(t, allowed, got, "%s has an invalid value", path)
+	}
+
+	assert.Regexp(t, `^15\.\d+\.\d+$`, str(doc, "version", "report"))
+
+	scan := object(doc["scan"], "scan")
+	for _, tool := range []string{"analyzer", "scanner"} {
+		m := object(scan[tool], "scan."+tool)
+		for _, key := range []string{"id", "name", "version"} {
+			str(m, key, "scan."+tool)
+		}
+		str(object(m["vendor"], "scan."+tool+".vendor"), "name", "scan."+tool+".vendor")
+	}
+	assert.Equal(t, "secret_detection", str(scan, "type", "scan"))
+	oneOf(str(scan, "status", "scan"), "scan.status", "success", "failure")
+	assert.Regexp(t, gitLabTimePattern, str(scan, "start_time", "scan"))
+	assert.Regexp(t, gitLabTimePattern, str(scan, "end_time", "scan"))
+
+	vulns, ok := doc["vulnerabilities"].([]any)
+	require.True(t, ok, "vulnerabilities must be an array")
+	for i, v := range vulns {
+		path := fmt.Sprintf("vulnerabilities[%d]", i)
+		vuln := object(v, path)
+		str(vuln, "id", path)
+		oneOf(str(vuln, "severity", path), path+".severity", "Info", "Unknown", "Low", "Medium", "High", "Critical")
+
+		identifiers, ok := vuln["identifiers"].([]any)
+		require.True(t, ok, "%s.identifiers must be an array", path)
+		require.NotEmpty(t, identifiers, "%s.identifiers must not be empty", path)
+		for j, id := range identifiers {
+			idPath := fmt.Sprintf("%s.identifiers[%d]", path, j)
+			m := object(id, idPath)
+			for _, key := range []string{"type", "name", "value"} {
+				str(m, key, idPath)
+			}
+		}
+		if links, ok := vuln["links"].([]any); ok {
+			for j, link := range links {
+				linkPath := fmt.Sprintf("%s.links[%d]", path, j)
+				str(object(link, linkPath), "url", linkPath)
+			}
+		}
+
+		location := object(vuln["location"], path+".location")
+		str(object(location["commit"], path+".location.commit"), "sha", path+".location.commit")
+	}
+}
+
+func TestGitLabSecretDetectionPrinter(t *testing.T) {
+	gitFinding := outputtest.GitResult("AKIAGITLAB", "https://gitlab.com/org/repo.git", "config.env", "abc123", 4, true)
+	fileFinding := &detectors.ResultWithMetadata{
+		SourceMetadata: &source_metadatapb.MetaData{
+			Data: &source_metadatapb.MetaData_Filesystem{
+				Filesystem: &source_metadatapb.Filesystem{File: "deploy/.env", Line: 7},
+			},
+		},
+		Result: detectors.Result{Raw: []byte("AKIAFILE")},
+	}
+
+	var out bytes.Buffer
+	p := NewGitLabSecretDetectionPrinter(&out)
+	require.NoError(t, p.Print(context.Background(), gitFinding))
+	require.NoError(t, p.Print(context.Background(), fileFinding))
+	require.NoError(t, p.Close())
+	validateGitLabReport(t, out.Bytes())
+
+	var report gitLabReport
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	require.Len(t, report.Vulnerabilities, 2)
+
+	git := report.Vulnerabilities[0]
+	assert.Equal(t, "Critical", git.Severity)
+	assert.Equal(t, gitLabLocation{File: "config.env", StartLine: 4, EndLine: 4, Commit: gitLabCommit{SHA: "abc123"}}, git.Location)
+	fingerprint, err := Fingerprint(gitFinding)
+	require.NoError(t, err)
+	assert.Equal(t, fingerprint, git.ID)
+
+	file := report.Vulnerabilities[1]
+	assert.Equal(t, "Medium", file.Severity)
+	assert.Equal(t, gitLabLocation{File: "deploy/.env", StartLine: 7, EndLine: 7, Commit: gitLabCommit{SHA: gitLabNoCommit}}, file.Location)
+	assert.NotContains(t, out.String(), "AKIAGITLAB", "the report must not contain raw secrets")
+}
+
+func TestGitLabSecretDetectionPrinter_Empty(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, NewGitLabSecretDetectionPrinter(&out).Close())
+	validateGitLabReport(t, out.Bytes())
+	assert.Contains(t, out.String(), `"vulnerabilities": []`)
+}
@@ -0,0 +1,188 @@
+package output
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/giturl"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/version"
+)
+
+//go:embed templates/report.html.tmpl
+var htmlReportTemplate string
+
+var htmlReport = template.Must(template.New("report").Parse(htmlReportTemplate))
+
+// HTMLPrinter is a printer that collects results and renders them as a single
+// self-contained HTML report when closed.
+type HTMLPrinter struct {
+	// ContextLines is the number of lines before and after each finding to
+	// show with the secret redacted. Zero disables context.
+	ContextLines int
+
+	mu sync.Mutex
+	w  io.Writer
+
+	total       htmlCounts
+	detectors   map[string]*htmlCounts
+	sourceIndex map[string]int
+	sources     []htmlSource
+}
+
+// NewHTMLPrinter creates an HTMLPrinter that writes the report to w. If w is
+// nil, the report is written to stdout.
+func NewHTMLPrinter(w io.Writer) *HTMLPrinter {
+	if w == nil {
+		w = os.Stdout
+	}
+	return &HTMLPrinter{
+		w:           w,
+		detectors:   make(map[string]*htmlCounts),
+		sourceIndex: make(map[string]int),
+	}
+}
+
+func (p *HTMLPrinter) Print(_ context.Context, r *detectors.ResultWithMetadata) error {
+	loc, err := LocationOf(r)
+	if err != nil {
+		return fmt.Errorf("could not marshal result: %w", err)
+	}
+
+	link := loc.Link
+	if link == "" && loc.Repository != "" && loc.Commit != "" {
+		// Links are built from the normalized repository, so credentials
+		// in the clone URL are not published in the report.
+		link = giturl.GenerateLink(NormalizeRepository(loc.Repository), loc.Commit, loc.File, loc.Line)
+	}
+	location := loc.File
+	if location != "" && loc.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, loc.Line)
+	}
+
+	finding := htmlFinding{
+		Status:      VerificationStatus(r),
+		Detector:    r.DetectorType.String(),
+		Decoder:     r.DecoderType.String(),
+		Redacted:    r.Redacted,
+		Location:    location,
+		Commit:      loc.Commit,
+		Email:       loc.Email,
+		Link:        link,
+		Context:     ContextOf(r, loc, p.ContextLines),
+		Remediation: remediationOf(r),
+	}
+	if err := r.VerificationError(); err != nil {
+		finding.VerificationError = err.Error()
+	}
+
+	name := sourceUnitName(r, loc)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	counts, ok := p.detectors[finding.Detector]
+	if !ok {
+		counts = &htmlCounts{Name: finding.Detector}
+		p.detectors[finding.Detector] = counts
+	}
+	counts.add(finding.Status)
+	p.total.add(finding.Status)
+
+	idx, ok := p.sourceIndex[name]
+	if !ok {
+		idx = len(p.sources)
+		p.sourceIndex[name] = idx
+		p.sources = append(p.sources, htmlSource{Name: name})
+	}
+	p.sources[idx].Findings = append(p.sources[idx].Findings, finding)
+
+	return nil
+}
+
+// Close renders the collected results as an HTML report. It must be called
+// once after the scan has finished.
+func (p *HTMLPrinter) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	detectorCounts := make([]htmlCounts, 0, len(p.detectors))
+	for _, c := range p.detectors {
+		detectorCounts = append(detectorCounts, *c)
+	}
+	// Show the detectors with the most live secrets first.
+	sort.Slice(detectorCounts, func(i, j int) bool {
+		a, b := detectorCounts[i], detectorCounts[j]
+		if a.Verified != b.Verified {
+			return a.Verified > b.Verified
+		}
+		if a.Total != b.Total {
+			return a.Total > b.Total
+		}
+		return a.Name < b.Name
+	})
+
+	data := struct {
+		GeneratedAt string
+		Version     string
+		Total       htmlCounts
+		Detectors   []htmlCounts
+		Sources     []htmlSource
+	}{
+		GeneratedAt: time.Now().UTC().Format(time.RFC1123),
+		Version:     version.BuildVersion,
+		Total:       p.total,
+		Detectors:   detectorCounts,
+		Sources:     p.sources,
+	}
+	if err := htmlReport.Execute(p.w, data); err != nil {
+		return fmt.Errorf("could not render HTML report: %w", err)
+	}
+	return nil
+}
+
+type htmlCounts struct {
+	Name       string
+	Total      int
+	Verified   int
+	Unverified int
+	Unknown    int
+}
+
+func (c *htmlCounts) add(status string) {
+	c.Total++
+	switch status {
+	case "verified":
+		c.Verified++
+	case "unknown":
+		c.Unknown++
+	default:
+		c.Unverified++
+	}
+}
+
+type htmlSource struct {
+	Name     string
+	Findings []htmlFinding
+}
+
+type htmlFinding struct {
+	Status            string
+	VerificationError string
+	Detector          string
+	Decoder           string
+	Redacted          string
+	Location          string
+	Commit            string
+	Email             string
+	Link              string
+	Context           *FindingContext
+	Remediation       *detectors.Remediation
+}
@@ -1 +1,118 @@
+package output
 
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// JSONPrinter is a printer that prints results in JSON format.
+type JSONPrinter struct {
+	mu sync.Mutex
+	// ContextLines is the number of redacted lines before and after the
+	// secret to include. Zero disables context.
+	ContextLines int
+	// out is where results are written. It defaults to stdout.
+	out io.Writer
+}
+
+func (p *JSONPrinter) Print(_ context.Context, r *detectors.ResultWithMetadata) error {
+	v := &struct {
+		// SourceMetadata contains source-specific contextual information.
+		SourceMetadata *source_metadatapb.MetaData
+		// SourceID is the ID of the source that the API uses to map secrets to specific sources.
+		SourceID sources.SourceID
+		// SourceType is the type of Source.
+		SourceType sourcespb.SourceType
+		// SourceName is the name of the Source.
+		SourceName string
+		// DetectorType is the type of Detector.
+		DetectorType detectorspb.DetectorType
+		// DetectorName is the string name of the DetectorType.
+		DetectorName string
+		// DetectorDescription is the description of the Detector.
+		DetectorDescription string
+		// DecoderName is the string name of the DecoderType.
+		DecoderName string
+		// Verified contains the verification status of the secret.
+		Verified bool
+		// VerificationError contains the error that occurred during verification, if any.
+		VerificationError string `json:",omitempty"`
+		// VerificationFromCache indicates whether this result was cached.
+		VerificationFromCache bool
+		// Raw contains the raw secret data.
+		Raw string
+		// RawV2 contains the raw secret identifier that is a combination of both the ID and the secret.
+		// This is used for secrets that are multi part and could have the same ID. Ex: AWS credentials
+		RawV2 string
+		// Redacted contains the redacted version of the raw secret identification data for display purposes.
+		// A secret ID should be used if available.
+		Redacted string
+		// ExtraData contains extra data that may be useful for the secret.
+		ExtraData map[string]string
+		// StructuredData contains structured data that may be useful for the secret.
+		StructuredData *detectorspb.StructuredData
+		// Line, Column and Offset locate the secret within the scanned file or
+		// object. They are omitted when unknown.
+		Line   int64 `json:",omitempty"`
+		Column int64 `json:",omitempty"`
+		Offset int64 `json:",omitempty"`
+		// Fingerprint identifies the finding across scans.
+		Fingerprint string
+		// Context contains the redacted lines surrounding the secret, if enabled.
+		Context *FindingContext `json:",omitempty"`
+		// Remediation tells how to rotate and revoke the secret, if the
+		// detector knows.
+		Remediation *detectors.Remediation `json:",omitempty"`
+	}{
+		SourceMetadata:        r.SourceMetadata,
+		SourceID:              r.SourceID,
+		SourceType:            r.SourceType,
+		SourceName:            r.SourceName,
+		DetectorType:          r.DetectorType,
+		DetectorName:          r.DetectorName,
+		DetectorDescription:   r.DetectorDescription,
+		DecoderName:           r.DecoderType.String(),
+		Verified:              r.Verified,
+		VerificationFromCache: r.VerificationFromCache,
+		Raw:                   string(r.Raw),
+		RawV2:                 string(r.RawV2),
+		Redacted:              r.Redacted,
+		ExtraData:             r.ExtraData,
+		StructuredData:        r.StructuredData,
+		Remediation:           remediationOf(r),
+	}
+	if err := r.VerificationError(); err != nil {
+		v.VerificationError = err.Error()
+	}
+	loc, err := LocationOf(r)
+	if err != nil {
+		return fmt.Errorf("could not locate result: %w", err)
+	}
+	v.Line, v.Column, v.Offset = loc.Line, loc.Column, loc.Offset
+	v.Fingerprint = fingerprintOf(r, loc)
+	v.Context = ContextOf(r, loc, p.ContextLines)
+
+	out, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("could not marshal result: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	w := p.out
+	if w == nil {
+		w = os.Stdout
+	}
+	_, err = fmt.Fprintln(w, string(out))
+	return err
+}
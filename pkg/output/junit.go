@@ -0,0 +1,195 @@
+package output
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+)
+
+// JUnitPrinter is a printer that collects results and writes them as a JUnit
+// XML report when closed. Results are grouped into one test suite per source
+// unit (repository, image, bucket or file). Verified secrets are reported as
+// failed test cases, all other results as skipped test cases.
+type JUnitPrinter struct {
+	mu sync.Mutex
+	w  io.Writer
+
+	suiteIndex map[string]int
+	suites     []junitTestSuite
+}
+
+// NewJUnitPrinter creates a JUnitPrinter that writes the report to w. If w is
+// nil, the report is written to stdout.
+func NewJUnitPrinter(w io.Writer) *JUnitPrinter {
+	if w == nil {
+		w = os.Stdout
+	}
+	return &JUnitPrinter{w: w, suiteIndex: make(map[string]int)}
+}
+
+func (p *JUnitPrinter) Print(_ context.Context, r *detectors.ResultWithMetadata) error {
+	loc, err := LocationOf(r)
+	if err != nil {
+		return fmt.Errorf("could not marshal result: %w", err)
+	}
+
+	tc := junitTestCase{
+		ClassName: r.DetectorType.String(),
+		Name:      junitCaseName(loc),
+	}
+	status := VerificationStatus(r)
+	message := fmt.Sprintf("%s %s secret", status, r.DetectorType.String())
+	if r.Verified {
+		tc.Failure = &junitFailure{
+			Message: message,
+			Type:    status,
+			Text:    junitDetail(r, loc),
+		}
+	} else {
+		if err := r.VerificationError(); err != nil {
+			message = fmt.Sprintf("%s: %s", message, err)
+		}
+		tc.Skipped = &junitSkipped{Message: message}
+	}
+
+	name := sourceUnitName(r, loc)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	idx, ok := p.suiteIndex[name]
+	if !ok {
+		idx = len(p.suites)
+		p.suiteIndex[name] = idx
+		p.suites = append(p.suites, junitTestSuite{Name: name})
+	}
+	suite := &p.suites[idx]
+	suite.Tests++
+	if tc.Failure != nil {
+		suite.Failures++
+	} else {
+		suite.Skipped++
+	}
+	suite.TestCases = append(suite.TestCases, tc)
+
+	return nil
+}
+
+// Close writes the collected results as a JUnit XML report. It must be called
+// once after the scan has finished.
+func (p *JUnitPrinter) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	report := junitTestSuites{Name: "TruffleHog", TestSuites: p.suites}
+	for _, suite := range p.suites {
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+	}
+
+	if _, err := io.WriteString(p.w, xml.Header); err != nil {
+		return fmt.Errorf("could not write JUnit report: %w", err)
+	}
+	enc := xml.NewEncoder(p.w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return fmt.Errorf("could not write JUnit report: %w", err)
+	}
+	if _, err := io.WriteString(p.w, "\n"); err != nil {
+		return fmt.Errorf("could not write JUnit report: %w", err)
+	}
+	return nil
+}
+
+func junitCaseName(loc ResultLocation) string {
+	name := loc.File
+	if name == "" {
+		name = "unknown location"
+	}
+	if loc.Line > 0 {
+		name = fmt.Sprintf("%s:%d", name, loc.Line)
+	}
+	if loc.Commit != "" {
+		name = fmt.Sprintf("%s@%s", name, loc.Commit)
+	}
+	return name
+}
+
+// junitDetail describes a result without ever including the raw secret.
+func junitDetail(r *detectors.ResultWithMetadata, loc ResultLocation) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Detector Type: %s\n", r.DetectorType.String())
+	fmt.Fprintf(&b, "Decoder Type: %s\n", r.DecoderType.String())
+	if r.Redacted != "" {
+		fmt.Fprintf(&b, "Redacted: %s\n", r.Redacted)
+	}
+	for _, f := range []struct{ name, value string }{
+		{"File", loc.File},
+		{"Commit", loc.Commit},
+		{"Repository", NormalizeRepository(loc.Repository)},
+		{"Email", loc.Email},
+		{"Link", loc.Link},
+	} {
+		if f.value != "" {
+			fmt.Fprintf(&b, "%s: %s\n", f.name, f.value)
+		}
+	}
+	if loc.Line > 0 {
+		fmt.Fprintf(&b, "Line: %d\n", loc.Line)
+	}
+	fmt.Fprintf(&b, "Fingerprint: %s\n", fingerprintOf(r, loc))
+	if rem := remediationOf(r); rem != nil {
+		for _, f := range []struct{ name, value string }{
+			{"Product", rem.Product},
+			{"Rotate", rem.RotationURL},
+			{"Revoke", rem.RevocationEndpoint},
+			{"Docs", rem.DocsURL},
+		} {
+			if f.value != "" {
+				fmt.Fprintf(&b, "%s: %s\n", f.name, f.value)
+			}
+		}
+	}
+	return b.String()
+}
+
+type junitTestSuites struct {
+	XMLName    xml.Name         `xml:"testsuites"`
+	Name       string           `xml:"name,attr"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	Skipped    int              `xml:"skipped,attr"`
+	TestSuites []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
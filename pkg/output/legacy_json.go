@@ -1 +1,205 @@
+package output
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/sergi/go-diff/diffmatchpatch"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/git"
+)
+
+// LegacyJSONPrinter is a printer that prints results in the JSON format of
+// TruffleHog v2. Only results of git based sources can be printed.
+type LegacyJSONPrinter struct {
+	mu sync.Mutex
+	// out is where results are written. It defaults to stdout.
+	out io.Writer
+}
+
+func (p *LegacyJSONPrinter) Print(ctx context.Context, r *detectors.ResultWithMetadata) error {
+	var repo string
+	switch r.SourceType {
+	case sourcespb.SourceType_SOURCE_TYPE_GIT:
+		repo = r.SourceMetadata.GetGit().Repository
+	case sourcespb.SourceType_SOURCE_TYPE_GITHUB:
+		repo = r.SourceMetadata.GetGithub().Repository
+	case sourcespb.SourceType_SOURCE_TYPE_GITLAB:
+		repo = r.SourceMetadata.GetGitlab().Repository
+	default:
+		return fmt.Errorf("unsupported source type for legacy json output: %s", r.SourceType)
+	}
+
+	// cloning the repo again here is not great and only works with unauthed repos
+	repoPath, remote, err := git.PrepareRepo(ctx, repo)
+	if err != nil || repoPath == "" {
+		return fmt.Errorf("error preparing git repo for scanning: %w", err)
+	}
+	if remote {
+		defer os.RemoveAll(repoPath)
+	}
+
+	legacy, err := convertToLegacyJSON(r, repoPath)
+	if err != nil {
+		return fmt.Errorf("could not convert to legacy JSON: %w", err)
+	}
+	out, err := json.Marshal(legacy)
+	if err != nil {
+		return fmt.Errorf("could not marshal result: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	w := p.out
+	if w == nil {
+		w = os.Stdout
+	}
+	_, err = fmt.Fprintln(w, string(out))
+	return err
+}
+
+func convertToLegacyJSON(r *detectors.ResultWithMetadata, repoPath string) (*LegacyJSONOutput, error) {
+	var source LegacyJSONCompatibleSource
+	switch r.SourceType {
+	case sourcespb.SourceType_SOURCE_TYPE_GIT:
+		source = r.SourceMetadata.GetGit()
+	case sourcespb.SourceType_SOURCE_TYPE_GITHUB:
+		source = r.SourceMetadata.GetGithub()
+	case sourcespb.SourceType_SOURCE_TYPE_GITLAB:
+		source = r.SourceMetadata.GetGitlab()
+	default:
+		return nil, fmt.Errorf("legacy JSON output can not be used with this source: %s", r.SourceName)
+	}
+
+	loc, err := LocationOf(r)
+	if err != nil {
+		return nil, err
+	}
+
+	options := &gogit.PlainOpenOptions{
+		DetectDotGit:          true,
+		EnableDotGitCommonDir: true,
+	}
+	repo, err := gogit.PlainOpenWithOptions(repoPath, options)
+	if err != nil {
+		return nil, fmt.Errorf("could not open repo: %s: %w", repoPath, err)
+	}
+
+	fileName := source.GetFile()
+	commitHash := plumbing.NewHash(source.GetCommit())
+	commit, err := repo.CommitObject(commitHash)
+	if err != nil {
+		return nil, fmt.Errorf("could not get commit %q from repo: %w", commitHash, err)
+	}
+
+	diff := GenerateDiff(commit, fileName)
+
+	foundString := string(r.Result.Raw)
+
+	// Add highlighting to the offending bit of string.
+	printableDiff := strings.ReplaceAll(diff, foundString, fmt.Sprintf("\u001b[93m%s\u001b[0m", foundString))
+
+	// Load up the struct to match the old JSON format
+	output := &LegacyJSONOutput{
+		Branch:       source.GetRepository(),
+		Commit:       commit.Message,
+		CommitHash:   commitHash.String(),
+		Date:         commit.Committer.When.Format("2006-01-02 15:04:05"),
+		Diff:         diff,
+		Path:         fileName,
+		PrintDiff:    printableDiff,
+		Reason:       r.Result.DetectorType.String(),
+		StringsFound: []string{foundString},
+		Fingerprint:  fingerprintOf(r, loc),
+		Remediation:  remediationOf(r),
+	}
+	return output, nil
+}
+
+// GenerateDiff will take a commit and create a string diff between the commit and its first parent.
+func GenerateDiff(commit *object.Commit, fileName string) string {
+	var diff string
+	// First grab the first parent of the commit. If there are none, we are at the first commit and should diff against
+	// an empty file.
+	parent, err := commit.Parent(0)
+	if !errors.Is(err, object.ErrParentNotFound) && err != nil {
+		return ""
+	}
+
+	// Now get the files from the commit and its parent.
+	var parentFile *object.File
+	if parent != nil {
+		parentFile, err = parent.File(fileName)
+		if err != nil && !errors.Is(err, object.ErrFileNotFound) {
+			return ""
+		}
+	}
+	commitFile, err := commit.File(fileName)
+	if err != nil {
+		return ""
+	}
+
+	// go-git doesn't support creating a diff for just one file in a commit, so another package is needed to generate
+	// the diff.
+	dmp := diffmatchpatch.New()
+	var oldContent, newContent string
+	if parentFile != nil {
+		oldContent, err = parentFile.Contents()
+		if err != nil {
+			return ""
+		}
+	}
+	newContent, err = commitFile.Contents()
+	if err != nil {
+		return ""
+	}
+	diffs := dmp.DiffMain(oldContent, newContent, false)
+	for _, d := range diffs {
+		switch d.Type {
+		case diffmatchpatch.DiffInsert:
+			diff += fmt.Sprintf("+%s\n", d.Text)
+		case diffmatchpatch.DiffDelete:
+			diff += fmt.Sprintf("-%s\n", d.Text)
+		}
+	}
+	return diff
+}
+
+// LegacyJSONOutput is a result in the JSON format of TruffleHog v2, with the
+// fingerprint of the finding added.
+type LegacyJSONOutput struct {
+	Branch       string   `json:"branch"`
+	Commit       string   `json:"commit"`
+	CommitHash   string   `json:"commitHash"`
+	Date         string   `json:"date"`
+	Diff         string   `json:"diff"`
+	Path         string   `json:"path"`
+	PrintDiff    string   `json:"printDiff"`
+	Reason       string   `json:"reason"`
+	StringsFound []string `json:"stringsFound"`
+	Fingerprint  string   `json:"fingerprint"`
+	// Remediation is not part of the v2 format and is omitted if the
+	// detector has none.
+	Remediation *detectors.Remediation `json:"remediation,omitempty"`
+}
+
+// LegacyJSONCompatibleSource is the metadata of the sources the legacy JSON
+// format supports.
+type LegacyJSONCompatibleSource interface {
+	GetCommit() string
+	GetFile() string
+	GetEmail() string
+	GetRepository() string
+	GetTimestamp() string
+}
@@ -0,0 +1,153 @@
+package output
+
+import (
+	"strings"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// ResultLocation is the subset of source metadata that most sources share
+// and that report formats and result sinks use to point a reader at a
+// finding.
+type ResultLocation struct {
+	File string
+	// Line, Column and Offset locate the secret within File or the scanned
+	// object. Line and Column are 1-based, Offset is in bytes. They are 0
+	// when unknown, and only Line is known for most sources.
+	Line       int64
+	Column     int64
+	Offset     int64
+	Commit     string
+	Repository string
+	Link       string
+	Email      string
+	Timestamp  string
+	Image      string
+	Bucket     string
+
+	// computed is set if Line, Column and Offset were computed from the chunk
+	// data rather than reported by the source. Computed positions are left
+	// out of fingerprints, which predate them.
+	computed bool
+}
+
+// LocationOf flattens the source specific metadata of a result into a
+// ResultLocation. Fields a source does not provide are left empty.
+func LocationOf(r *detectors.ResultWithMetadata) (ResultLocation, error) {
+	var loc ResultLocation
+	meta, err := structToMap(r.SourceMetadata.GetData())
+	if err != nil {
+		return loc, err
+	}
+
+	for _, data := range meta {
+		for k, v := range data {
+			switch val := v.(type) {
+			case string:
+				switch k {
+				case "file":
+					loc.File = val
+				case "filename":
+					// Object storage sources name the field differently.
+					if loc.File == "" {
+						loc.File = val
+					}
+				case "commit":
+					loc.Commit = val
+				case "repository":
+					loc.Repository = val
+				case "link":
+					loc.Link = val
+				case "email":
+					loc.Email = val
+				case "timestamp":
+					loc.Timestamp = val
+				case "image":
+					loc.Image = val
+				case "bucket":
+					loc.Bucket = val
+				}
+			case float64:
+				if k == "line" {
+					loc.Line = int64(val)
+				}
+			}
+		}
+	}
+
+	if loc.Line == 0 && wholeObjectChunks(meta) {
+		if pos, ok := detectors.PositionOf(r.Data, r.Raw, detectors.ObjectStart); ok {
+			loc.Line, loc.Column, loc.Offset = pos.Line, pos.Column, pos.Offset
+			loc.computed = true
+		}
+	}
+	return loc, nil
+}
+
+// ChunkLocation returns the most specific location the chunk's metadata
+// names, for messages about the chunk rather than about a result.
+func ChunkLocation(chunk *sources.Chunk) string {
+	loc, err := LocationOf(&detectors.ResultWithMetadata{SourceMetadata: chunk.SourceMetadata})
+	if err != nil {
+		return ""
+	}
+	switch {
+	case loc.Link != "":
+		return loc.Link
+	case loc.File != "" && loc.Commit != "":
+		return loc.File + "@" + loc.Commit
+	case loc.File != "":
+		return loc.File
+	case loc.Commit != "":
+		return loc.Commit
+	case loc.Image != "":
+		return loc.Image
+	case loc.Bucket != "":
+		return loc.Bucket
+	}
+	return loc.Repository
+}
+
+// dockerHistoryFile is the prefix of the file name the Docker source gives
+// image history entries.
+const dockerHistoryFile = "image-metadata:history:"
+
+// wholeObjectChunks reports whether the metadata belongs to a chunk that holds
+// a whole object, such as a build log, a document or an image history entry.
+// The position of a secret within the chunk is then its position within the
+// object. Files in Docker layers and GCS objects are split into overlapping
+// chunks, and their metadata does not say where in the object a chunk starts.
+func wholeObjectChunks(meta map[string]map[string]any) bool {
+	for name, fields := range meta {
+		switch name {
+		case "Jenkins", "Elasticsearch":
+			return true
+		case "Docker":
+			file, _ := fields["file"].(string)
+			return strings.HasPrefix(file, dockerHistoryFile)
+		}
+	}
+	return false
+}
+
+// sourceUnitName returns the name of the source unit a result belongs to,
+// which is the most specific container of the finding the metadata names.
+// Repositories are normalized, so clone URLs with credentials are grouped
+// with the plain URL and never end up in a report.
+func sourceUnitName(r *detectors.ResultWithMetadata, loc ResultLocation) string {
+	switch {
+	case loc.Repository != "":
+		return NormalizeRepository(loc.Repository)
+	case loc.Image != "":
+		return loc.Image
+	case loc.Bucket != "":
+		return loc.Bucket
+	case loc.File != "":
+		return loc.File
+	case r.SourceName != "":
+		return r.SourceName
+	default:
+		return r.SourceType.String()
+	}
+}
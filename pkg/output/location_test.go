@@ -0,0 +1,127 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+func TestLocationOf_Position(t *testing.T) {
+	buildLog := []byte("Started by user admin\n+ export TOKEN=secret123\nFinished: SUCCESS\n")
+	tests := []struct {
+		name   string
+		result *detectors.ResultWithMetadata
+		want   ResultLocation
+	}{
+		{
+			name: "whole build log",
+			result: &detectors.ResultWithMetadata{
+				SourceMetadata: &source_metadatapb.MetaData{
+					Data: &source_metadatapb.MetaData_Jenkins{
+						Jenkins: &source_metadatapb.Jenkins{ProjectName: "deploy", BuildNumber: 7, Link: "https://jenkins/job/deploy/7/consoleText"},
+					},
+				},
+				Result: detectors.Result{Raw: []byte("secret123")},
+				Data:   buildLog,
+			},
+			want: ResultLocation{Link: "https://jenkins/job/deploy/7/consoleText", Line: 2, Column: 16, Offset: 37, computed: true},
+		},
+		{
+			name: "whole document",
+			result: &detectors.ResultWithMetadata{
+				SourceMetadata: &source_metadatapb.MetaData{
+					Data: &source_metadatapb.MetaData_Elasticsearch{
+						Elasticsearch: &source_metadatapb.Elasticsearch{Index: "logs", DocumentId: "1", Timestamp: "2024-01-01"},
+					},
+				},
+				Result: detectors.Result{Raw: []byte("secret123")},
+				Data:   []byte("token=secret123"),
+			},
+			want: ResultLocation{Timestamp: "2024-01-01", Line: 1, Column: 7, Offset: 6, computed: true},
+		},
+		{
+			name: "image history entry",
+			result: &detectors.ResultWithMetadata{
+				SourceMetadata: &source_metadatapb.MetaData{
+					Data: &source_metadatapb.MetaData_Docker{Docker: &source_metadatapb.Docker{File: "image-metadata:history:3:created-by", Image: "app"}},
+				},
+				Result: detectors.Result{Raw: []byte("secret123")},
+				Data:   []byte("/bin/sh -c export TOKEN=secret123"),
+			},
+			want: ResultLocation{File: "image-metadata:history:3:created-by", Image: "app", Line: 1, Column: 25, Offset: 24, computed: true},
+		},
+		{
+			name: "secret not in the chunk data",
+			result: &detectors.ResultWithMetadata{
+				SourceMetadata: &source_metadatapb.MetaData{
+					Data: &source_metadatapb.MetaData_Jenkins{Jenkins: &source_metadatapb.Jenkins{ProjectName: "deploy"}},
+				},
+				Result: detectors.Result{Raw: []byte("decoded")},
+				Data:   buildLog,
+			},
+			want: ResultLocation{},
+		},
+		{
+			name: "chunk of a larger object",
+			result: &detectors.ResultWithMetadata{
+				SourceMetadata: &source_metadatapb.MetaData{
+					Data: &source_metadatapb.MetaData_Docker{Docker: &source_metadatapb.Docker{File: "/etc/app.conf", Image: "app"}},
+				},
+				Result: detectors.Result{Raw: []byte("secret123")},
+				Data:   buildLog,
+			},
+			want: ResultLocation{File: "/etc/app.conf", Image: "app"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc, err := LocationOf(tt.result)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, loc)
+		})
+	}
+}
+
+func TestFingerprint_IgnoresComputedPosition(t *testing.T) {
+	r := &detectors.ResultWithMetadata{
+		SourceMetadata: &source_metadatapb.MetaData{
+			Data: &source_metadatapb.MetaData_Jenkins{Jenkins: &source_metadatapb.Jenkins{ProjectName: "deploy", BuildNumber: 7}},
+		},
+		Result: detectors.Result{Raw: []byte("secret123")},
+	}
+	before, err := Fingerprint(r)
+	require.NoError(t, err)
+
+	r.Data = []byte("\n\ntoken=secret123")
+	after, err := Fingerprint(r)
+	require.NoError(t, err)
+	assert.Equal(t, before, after, "positions computed from the chunk must not change fingerprints")
+}
+
+func TestChunkLocation(t *testing.T) {
+	for _, tt := range []struct {
+		meta *source_metadatapb.MetaData
+		want string
+	}{
+		{
+			meta: &source_metadatapb.MetaData{Data: &source_metadatapb.MetaData_Git{Git: &source_metadatapb.Git{File: "main.go", Commit: "abc123", Repository: "https://github.com/org/repo"}}},
+			want: "main.go@abc123",
+		},
+		{
+			meta: &source_metadatapb.MetaData{Data: &source_metadatapb.MetaData_Filesystem{Filesystem: &source_metadatapb.Filesystem{File: "huge.min.js"}}},
+			want: "huge.min.js",
+		},
+		{
+			meta: &source_metadatapb.MetaData{Data: &source_metadatapb.MetaData_Jenkins{Jenkins: &source_metadatapb.Jenkins{Link: "https://ci/job/1"}}},
+			want: "https://ci/job/1",
+		},
+		{want: ""},
+	} {
+		assert.Equal(t, tt.want, ChunkLocation(&sources.Chunk{SourceMetadata: tt.meta}))
+	}
+}
@@ -0,0 +1,154 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+)
+
+// Printer is implemented by every result printer and by the filters that sit
+// in front of one. It has the same method set as engine.Printer.
+type Printer interface {
+	Print(ctx context.Context, r *detectors.ResultWithMetadata) error
+}
+
+// ResultsFilter selects results by verification status. The zero value
+// selects all results.
+type ResultsFilter struct {
+	Verified   bool
+	Unverified bool
+	Unknown    bool
+	// FilteredUnverified selects unverified results that the engine's false
+	// positive filtering would otherwise drop. The engine only keeps them if
+	// it is configured to, see MultiPrinter.FilteredUnverified, and marks
+	// them with MarkFalsePositive. Marked results only reach the sinks that
+	// select them explicitly, so asking for them in one sink does not leak
+	// false positives into the others.
+	FilteredUnverified bool
+}
+
+// FalsePositiveKey is the ExtraData key under which MarkFalsePositive stores
+// why a result is a false positive.
+const FalsePositiveKey = "false_positive"
+
+// MarkFalsePositive marks r as a known false positive that was only kept
+// because a sink selects filtered unverified results.
+func MarkFalsePositive(r *detectors.Result, reason string) {
+	if reason == "" {
+		reason = "known false positive"
+	}
+	if r.ExtraData == nil {
+		r.ExtraData = make(map[string]string)
+	}
+	r.ExtraData[FalsePositiveKey] = reason
+}
+
+// IsFalsePositive reports whether r was marked with MarkFalsePositive.
+func IsFalsePositive(r *detectors.ResultWithMetadata) bool {
+	_, ok := r.ExtraData[FalsePositiveKey]
+	return ok
+}
+
+// ParseResultsFilter parses a comma separated list of "verified",
+// "unverified", "unknown" and "filtered_unverified", as accepted by
+// --results. An empty string selects all results.
+func ParseResultsFilter(s string) (ResultsFilter, error) {
+	var f ResultsFilter
+	for _, v := range strings.Split(s, ",") {
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "":
+		case "verified":
+			f.Verified = true
+		case "unverified":
+			f.Unverified = true
+		case "unknown":
+			f.Unknown = true
+		case "filtered_unverified":
+			f.FilteredUnverified = true
+		default:
+			return ResultsFilter{}, fmt.Errorf("invalid results filter %q", v)
+		}
+	}
+	return f, nil
+}
+
+// Allows reports whether the filter selects r. Results marked as false
+// positives are only selected by FilteredUnverified, even by the zero value.
+func (f ResultsFilter) Allows(r *detectors.ResultWithMetadata) bool {
+	if IsFalsePositive(r) {
+		return f.FilteredUnverified
+	}
+	if f == (ResultsFilter{}) {
+		return true
+	}
+	switch VerificationStatus(r) {
+	case "verified":
+		return f.Verified
+	case "unknown":
+		return f.Unknown
+	default:
+		return f.Unverified
+	}
+}
+
+// Sink is a printer together with the results it should receive.
+type Sink struct {
+	Printer Printer
+	Results ResultsFilter
+}
+
+// MultiPrinter is a printer that fans each result out to several sinks, so a
+// single scan can produce e.g. plain output on the terminal and SARIF in a
+// file. Each sink applies its own results filter.
+type MultiPrinter struct {
+	sinks []Sink
+}
+
+// NewMultiPrinter creates a MultiPrinter for the given sinks.
+func NewMultiPrinter(sinks ...Sink) *MultiPrinter {
+	return &MultiPrinter{sinks: sinks}
+}
+
+// FilteredUnverified reports whether any sink selects filtered unverified
+// results, in which case the engine must be configured to keep them.
+func (p *MultiPrinter) FilteredUnverified() bool {
+	for _, s := range p.sinks {
+		if s.Results.FilteredUnverified {
+			return true
+		}
+	}
+	return false
+}
+
+// Print passes r to every sink that accepts it. A failing sink does not keep
+// the result from reaching the others.
+func (p *MultiPrinter) Print(ctx context.Context, r *detectors.ResultWithMetadata) error {
+	var errs []error
+	for _, s := range p.sinks {
+		if !s.Results.Allows(r) {
+			continue
+		}
+		if err := s.Printer.Print(ctx, r); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes every sink printer that implements io.Closer, such as the
+// report printers that only write their output at the end of a scan.
+func (p *MultiPrinter) Close() error {
+	var errs []error
+	for _, s := range p.sinks {
+		if c, ok := s.Printer.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,70 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/output/outputtest"
+)
+
+type countingPrinter struct{ count int }
+
+func (p *countingPrinter) Print(context.Context, *detectors.ResultWithMetadata) error {
+	p.count++
+	return nil
+}
+
+func TestMultiPrinter(t *testing.T) {
+	ctx := context.Background()
+
+	onlyVerified, err := ParseResultsFilter("verified")
+	require.NoError(t, err)
+	verifiedAndUnknown, err := ParseResultsFilter("verified, unknown")
+	require.NoError(t, err)
+	onlyUnverified, err := ParseResultsFilter("unverified")
+	require.NoError(t, err)
+	filteredUnverified, err := ParseResultsFilter("filtered_unverified")
+	require.NoError(t, err)
+	_, err = ParseResultsFilter("maybe")
+	assert.Error(t, err)
+
+	all, verified, verifiedOrUnknown, unverified, filtered := &countingPrinter{}, &countingPrinter{}, &countingPrinter{}, &countingPrinter{}, &countingPrinter{}
+	var csvOut bytes.Buffer
+	p := NewMultiPrinter(
+		Sink{Printer: all},
+		Sink{Printer: verified, Results: onlyVerified},
+		Sink{Printer: verifiedOrUnknown, Results: verifiedAndUnknown},
+		Sink{Printer: unverified, Results: onlyUnverified},
+		Sink{Printer: filtered, Results: filteredUnverified},
+		Sink{Printer: NewCSVPrinter(&csvOut), Results: onlyVerified},
+	)
+	assert.True(t, p.FilteredUnverified())
+	assert.False(t, NewMultiPrinter(Sink{Printer: all, Results: onlyVerified}).FilteredUnverified())
+
+	unknown := outputtest.GitResult("c", "repo", "c.txt", "c1", 3, false)
+	unknown.SetVerificationError(fmt.Errorf("timeout"))
+	falsePositive := outputtest.GitResult("example", "repo", "d.txt", "c1", 4, false)
+	MarkFalsePositive(&falsePositive.Result, "")
+	assert.True(t, IsFalsePositive(falsePositive))
+
+	require.NoError(t, p.Print(ctx, outputtest.GitResult("a", "repo", "a.txt", "c1", 1, true)))
+	require.NoError(t, p.Print(ctx, outputtest.GitResult("b", "repo", "b.txt", "c1", 2, false)))
+	require.NoError(t, p.Print(ctx, unknown))
+	require.NoError(t, p.Print(ctx, falsePositive))
+	require.NoError(t, p.Close())
+
+	// The false positive only reaches the sink that asked for it.
+	assert.Equal(t, 3, all.count)
+	assert.Equal(t, 1, verified.count)
+	assert.Equal(t, 2, verifiedOrUnknown.count)
+	assert.Equal(t, 1, unverified.count)
+	assert.Equal(t, 1, filtered.count)
+	// Header plus the verified result.
+	assert.Equal(t, 2, bytes.Count(csvOut.Bytes(), []byte("\n")))
+}
@@ -0,0 +1,29 @@
+// Package outputtest provides result fixtures for tests of printers and of
+// the filters in front of them.
+package outputtest
+
+import (
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+)
+
+// GitResult returns a result for the secret raw as the git source reports it,
+// found at line of file in commit of repo. Tests set any other fields, such
+// as the detector type, on the returned result.
+func GitResult(raw, repo, file, commit string, line int64, verified bool) *detectors.ResultWithMetadata {
+	return &detectors.ResultWithMetadata{
+		SourceType: sourcespb.SourceType_SOURCE_TYPE_GIT,
+		SourceMetadata: &source_metadatapb.MetaData{
+			Data: &source_metadatapb.MetaData_Git{
+				Git: &source_metadatapb.Git{
+					Repository: repo,
+					File:       file,
+					Commit:     commit,
+					Line:       line,
+				},
+			},
+		},
+		Result: detectors.Result{Raw: []byte(raw), Verified: verified},
+	}
+}
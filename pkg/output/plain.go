@@ -1 +1,121 @@
+package output
 
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+)
+
+var (
+	yellowPrinter     = color.New(color.FgYellow)
+	greenPrinter      = color.New(color.FgHiGreen)
+	boldGreenPrinter  = color.New(color.Bold, color.FgHiGreen)
+	boldYellowPrinter = color.New(color.Bold, color.FgYellow)
+	whitePrinter      = color.New(color.FgWhite)
+	boldWhitePrinter  = color.New(color.Bold, color.FgWhite)
+)
+
+// PlainPrinter is a printer that prints results in plain text format.
+type PlainPrinter struct {
+	mu sync.Mutex
+	// ContextLines is the number of redacted lines before and after the
+	// secret to include. Zero disables context.
+	ContextLines int
+	// out is where results are written. It defaults to stdout.
+	out io.Writer
+}
+
+func (p *PlainPrinter) Print(_ context.Context, r *detectors.ResultWithMetadata) error {
+	meta, err := structToMap(r.SourceMetadata.GetData())
+	if err != nil {
+		return fmt.Errorf("could not marshal result: %w", err)
+	}
+	var findingContext *FindingContext
+	if p.ContextLines > 0 {
+		loc, err := LocationOf(r)
+		if err != nil {
+			return fmt.Errorf("could not locate result: %w", err)
+		}
+		findingContext = ContextOf(r, loc, p.ContextLines)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	w := p.out
+	if w == nil {
+		w = os.Stdout
+	}
+
+	printer := greenPrinter
+	verificationErr := r.VerificationError()
+	switch {
+	case r.Verified:
+		boldGreenPrinter.Fprint(w, "✅ Found verified result 🐷🔑\n")
+	case verificationErr != nil:
+		printer = yellowPrinter
+		boldYellowPrinter.Fprint(w, "⚠️ Found result - unable to verify due to error 🐷🔑❗️\n")
+		printer.Fprintf(w, "Verification Error: %s\n", verificationErr)
+	default:
+		printer = whitePrinter
+		boldWhitePrinter.Fprint(w, "Found unverified result 🐷🔑❓\n")
+	}
+	printer.Fprintf(w, "Detector Type: %s\n", r.DetectorType.String())
+	printer.Fprintf(w, "Decoder Type: %s\n", r.DecoderType.String())
+	printer.Fprintf(w, "Raw result: %s\n", whitePrinter.Sprint(strings.TrimSpace(string(r.Raw))))
+
+	title := cases.Title(language.AmericanEnglish)
+	extraKeys := make([]string, 0, len(r.ExtraData))
+	for k := range r.ExtraData {
+		extraKeys = append(extraKeys, k)
+	}
+	sort.Strings(extraKeys)
+	for _, k := range extraKeys {
+		printer.Fprintf(w, "%s: %v\n", title.String(k), r.ExtraData[k])
+	}
+
+	aggregateData := make(map[string]any)
+	var aggregateDataKeys []string
+	for _, data := range meta {
+		for k, v := range data {
+			aggregateDataKeys = append(aggregateDataKeys, k)
+			aggregateData[k] = v
+		}
+	}
+	sort.Strings(aggregateDataKeys)
+	for _, k := range aggregateDataKeys {
+		printer.Fprintf(w, "%s: %v\n", title.String(k), aggregateData[k])
+	}
+
+	if steps := remediationSteps(remediationOf(r)); steps != "" {
+		printer.Fprintf(w, "Remediation: %s\n", steps)
+	}
+
+	if findingContext != nil {
+		printer.Fprint(w, "Context:\n")
+		for _, line := range findingContext.Lines {
+			printer.Fprintf(w, "    %s\n", line)
+		}
+	}
+	fmt.Fprintln(w)
+	return nil
+}
+
+func structToMap(obj any) (m map[string]map[string]any, err error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(data, &m)
+	return
+}
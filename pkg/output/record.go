@@ -0,0 +1,82 @@
+package output
+
+import (
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+)
+
+// RecordOptions controls what NewResultRecord includes.
+type RecordOptions struct {
+	// IncludeSecrets adds the raw secret. It is off by default so that
+	// secrets do not leak into the receiving system.
+	IncludeSecrets bool
+	// ContextLines is the number of redacted lines before and after the
+	// secret to include. Zero disables context.
+	ContextLines int
+}
+
+// ResultRecord is the JSON representation of a result used by the sinks that
+// send results to other services.
+type ResultRecord struct {
+	Fingerprint       string                    `json:"fingerprint"`
+	DetectorType      string                    `json:"detector_type"`
+	DetectorName      string                    `json:"detector_name"`
+	DecoderType       string                    `json:"decoder_type"`
+	Verified          bool                      `json:"verified"`
+	Status            string                    `json:"status"`
+	VerificationError string                    `json:"verification_error,omitempty"`
+	Raw               string                    `json:"raw,omitempty"`
+	RawV2             string                    `json:"raw_v2,omitempty"`
+	Redacted          string                    `json:"redacted,omitempty"`
+	SourceID          int64                     `json:"source_id"`
+	JobID             int64                     `json:"job_id"`
+	SourceType        string                    `json:"source_type"`
+	SourceName        string                    `json:"source_name"`
+	SourceMetadata    map[string]map[string]any `json:"source_metadata"`
+	Line              int64                     `json:"line,omitempty"`
+	Column            int64                     `json:"column,omitempty"`
+	Offset            int64                     `json:"offset,omitempty"`
+	ExtraData         map[string]string         `json:"extra_data,omitempty"`
+	Context           *FindingContext           `json:"context,omitempty"`
+	Remediation       *detectors.Remediation    `json:"remediation,omitempty"`
+}
+
+// NewResultRecord converts a result to a ResultRecord.
+func NewResultRecord(r *detectors.ResultWithMetadata, opts RecordOptions) (ResultRecord, error) {
+	loc, err := LocationOf(r)
+	if err != nil {
+		return ResultRecord{}, err
+	}
+	metadata, err := structToMap(r.SourceMetadata.GetData())
+	if err != nil {
+		return ResultRecord{}, err
+	}
+
+	rec := ResultRecord{
+		Fingerprint:    fingerprintOf(r, loc),
+		DetectorType:   r.DetectorType.String(),
+		DetectorName:   r.DetectorName,
+		DecoderType:    r.DecoderType.String(),
+		Verified:       r.Verified,
+		Status:         VerificationStatus(r),
+		Redacted:       r.Redacted,
+		SourceID:       int64(r.SourceID),
+		JobID:          int64(r.JobID),
+		SourceType:     r.SourceType.String(),
+		SourceName:     r.SourceName,
+		SourceMetadata: metadata,
+		Line:           loc.Line,
+		Column:         loc.Column,
+		Offset:         loc.Offset,
+		ExtraData:      r.ExtraData,
+		Context:        ContextOf(r, loc, opts.ContextLines),
+		Remediation:    remediationOf(r),
+	}
+	if err := r.VerificationError(); err != nil {
+		rec.VerificationError = err.Error()
+	}
+	if opts.IncludeSecrets {
+		rec.Raw = string(r.Raw)
+		rec.RawV2 = string(r.RawV2)
+	}
+	return rec, nil
+}
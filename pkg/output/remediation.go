@@ -0,0 +1,36 @@
+package output
+
+import (
+	"strings"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+)
+
+// remediationOf returns the remediation registered for the detector that
+// found r, or nil if there is none.
+func remediationOf(r *detectors.ResultWithMetadata) *detectors.Remediation {
+	rem, ok := detectors.RemediationFor(r.DetectorType)
+	if !ok {
+		return nil
+	}
+	return &rem
+}
+
+// remediationSteps describes a remediation in a sentence or two for formats
+// that only have room for free text.
+func remediationSteps(rem *detectors.Remediation) string {
+	if rem == nil {
+		return ""
+	}
+	var steps []string
+	if rem.RotationURL != "" {
+		steps = append(steps, "Rotate the secret at "+rem.RotationURL+".")
+	}
+	if rem.RevocationEndpoint != "" {
+		steps = append(steps, "Revoke it with "+rem.RevocationEndpoint+".")
+	}
+	if rem.DocsURL != "" {
+		steps = append(steps, "See "+rem.DocsURL+".")
+	}
+	return strings.Join(steps, " ")
+}
@@ -0,0 +1,108 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	logContext "github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/output/outputtest"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+)
+
+type remediatingDetector struct{}
+
+func (remediatingDetector) FromData(context.Context, bool, []byte) ([]detectors.Result, error) {
+	return nil, nil
+}
+func (remediatingDetector) Keywords() []string             { return nil }
+func (remediatingDetector) Type() detectorspb.DetectorType { return detectorspb.DetectorType_Stripe }
+func (remediatingDetector) Description() string            { return "" }
+
+func (remediatingDetector) Remediation() detectors.Remediation {
+	return detectors.Remediation{
+		Product:            "Stripe",
+		RotationURL:        "https://rotate.example.com",
+		RevocationEndpoint: "https://revoke.example.com",
+		DocsURL:            "https://docs.example.com",
+	}
+}
+
+func TestRemediationInOutputs(t *testing.T) {
+	ctx := logContext.Background()
+	detectors.RegisterRemediation(remediatingDetector{})
+
+	withRemediation := outputtest.GitResult("sk_live_secret", "https://github.com/org/repo.git", "config.yml", "abc123", 3, true)
+	withRemediation.DetectorType = detectorspb.DetectorType_Stripe
+	without := outputtest.GitResult("AKIA", "https://github.com/org/repo.git", "config.yml", "abc123", 4, true)
+	without.DetectorType = detectorspb.DetectorType_AWS
+
+	rec, err := NewResultRecord(withRemediation, RecordOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, rec.Remediation)
+	assert.Equal(t, "https://rotate.example.com", rec.Remediation.RotationURL)
+	rec, err = NewResultRecord(without, RecordOptions{})
+	require.NoError(t, err)
+	assert.Nil(t, rec.Remediation)
+
+	var (
+		csvOut, sarifOut, gitLabOut, junitOut, htmlOut bytes.Buffer
+	)
+	printers := []interface {
+		Print(logContext.Context, *detectors.ResultWithMetadata) error
+		Close() error
+	}{
+		NewCSVPrinter(&csvOut),
+		NewSARIFPrinter(&sarifOut),
+		NewGitLabSecretDetectionPrinter(&gitLabOut),
+		NewJUnitPrinter(&junitOut),
+		NewHTMLPrinter(&htmlOut),
+	}
+	for _, p := range printers {
+		require.NoError(t, p.Print(ctx, withRemediation))
+		require.NoError(t, p.Print(ctx, without))
+		require.NoError(t, p.Close())
+	}
+
+	for name, out := range map[string]*bytes.Buffer{
+		"csv":    &csvOut,
+		"sarif":  &sarifOut,
+		"gitlab": &gitLabOut,
+		"junit":  &junitOut,
+		"html":   &htmlOut,
+	} {
+		assert.Contains(t, out.String(), "https://rotate.example.com", name)
+		assert.Contains(t, out.String(), "https://docs.example.com", name)
+	}
+	assert.Contains(t, csvOut.String(), "product,rotation_url,revocation_endpoint,docs_url")
+	assert.Contains(t, sarifOut.String(), `"helpUri": "https://docs.example.com"`)
+	assert.Contains(t, junitOut.String(), "Revoke: https://revoke.example.com")
+
+	var jsonOut, plainOut, actionsOut, dedupOut bytes.Buffer
+	jsonPrinter := &JSONPrinter{out: &jsonOut}
+	plainPrinter := &PlainPrinter{out: &plainOut}
+	actionsPrinter := &GitHubActionsPrinter{out: &actionsOut}
+	dedupPrinter := NewDedupPrinter(&dedupOut)
+	for _, p := range []interface {
+		Print(logContext.Context, *detectors.ResultWithMetadata) error
+	}{jsonPrinter, plainPrinter, actionsPrinter, dedupPrinter} {
+		require.NoError(t, p.Print(ctx, withRemediation))
+	}
+	require.NoError(t, dedupPrinter.Close())
+
+	for name, out := range map[string]*bytes.Buffer{
+		"json":           &jsonOut,
+		"plain":          &plainOut,
+		"github-actions": &actionsOut,
+		"dedup":          &dedupOut,
+	} {
+		assert.Contains(t, out.String(), "https://rotate.example.com", name)
+	}
+	assert.Contains(t, jsonOut.String(), `"Remediation":{"product":"Stripe"`)
+	assert.Contains(t, dedupOut.String(), `"remediation":{"product":"Stripe"`)
+	assert.Contains(t, plainOut.String(), "Remediation: Rotate the secret at https://rotate.example.com.")
+}
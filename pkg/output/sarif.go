@@ -0,0 +1,323 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/version"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	sarifFingerprintKey = "trufflehogFingerprint/v" + fingerprintVersion
+)
+
+// SARIFPrinter is a printer that collects results and writes them as a single
+// SARIF 2.1.0 log when closed. Each detector type becomes a rule and each
+// result becomes a SARIF result referencing that rule.
+type SARIFPrinter struct {
+	// ContextLines is the number of lines before and after each finding to
+	// include as a redacted context region. Zero disables context.
+	ContextLines int
+
+	mu sync.Mutex
+	w  io.Writer
+
+	ruleIndex map[detectorspb.DetectorType]int
+	rules     []sarifRule
+	results   []sarifResult
+	// revisionIndex maps a repository and commit to its entry in
+	// revisions, which locations refer to by uriBaseId.
+	revisionIndex map[sarifRevisionKey]int
+	revisions     []sarifVersionControlDetails
+}
+
+type sarifRevisionKey struct {
+	repository, commit string
+}
+
+// NewSARIFPrinter creates a SARIFPrinter that writes the log to w. If w is
+// nil, the log is written to stdout.
+func NewSARIFPrinter(w io.Writer) *SARIFPrinter {
+	if w == nil {
+		w = os.Stdout
+	}
+	return &SARIFPrinter{
+		w:             w,
+		ruleIndex:     make(map[detectorspb.DetectorType]int),
+		revisionIndex: make(map[sarifRevisionKey]int),
+	}
+}
+
+func (p *SARIFPrinter) Print(_ context.Context, r *detectors.ResultWithMetadata) error {
+	loc, err := LocationOf(r)
+	if err != nil {
+		return fmt.Errorf("could not marshal result: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	idx, ok := p.ruleIndex[r.DetectorType]
+	if !ok {
+		idx = len(p.rules)
+		p.ruleIndex[r.DetectorType] = idx
+		p.rules = append(p.rules, newSARIFRule(r))
+	}
+
+	res := sarifResult{
+		RuleID:    r.DetectorType.String(),
+		RuleIndex: idx,
+		Level:     sarifLevel(r),
+		Message: sarifMessage{
+			Text: fmt.Sprintf("Found %s %s secret", VerificationStatus(r), r.DetectorType.String()),
+		},
+		PartialFingerprints: map[string]string{
+			sarifFingerprintKey: fingerprintOf(r, loc),
+		},
+		Properties: sarifResultProperties{
+			Verified:    r.Verified,
+			Decoder:     r.DecoderType.String(),
+			Redacted:    r.Redacted,
+			SourceName:  r.SourceName,
+			SourceType:  r.SourceType.String(),
+			Commit:      loc.Commit,
+			Repository:  NormalizeRepository(loc.Repository),
+			Email:       loc.Email,
+			Link:        loc.Link,
+			Remediation: remediationOf(r),
+		},
+	}
+	if err := r.VerificationError(); err != nil {
+		res.Properties.VerificationError = err.Error()
+	}
+	if loc.File != "" {
+		physical := sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: loc.File}}
+		if loc.Repository != "" && loc.Commit != "" {
+			// The file is relative to the root of the repository at the
+			// commit, which is described by the run's version control
+			// provenance.
+			physical.ArtifactLocation.URIBaseID = p.revision(loc.Repository, loc.Commit)
+		}
+		if loc.Line > 0 {
+			physical.Region = &sarifRegion{StartLine: loc.Line, StartColumn: loc.Column}
+		}
+		// SARIF regions need line numbers, so context is only added for
+		// sources that report them.
+		if c := ContextOf(r, loc, p.ContextLines); c != nil && c.StartLine > 0 {
+			physical.ContextRegion = &sarifRegion{
+				StartLine: c.StartLine,
+				EndLine:   c.EndLine(),
+				Snippet:   &sarifMessage{Text: c.String()},
+			}
+		}
+		res.Locations = []sarifLocation{{PhysicalLocation: physical}}
+	}
+	p.results = append(p.results, res)
+
+	return nil
+}
+
+// revision returns the uriBaseId of the root of repository at commit, adding
+// it to the version control provenance of the run if needed.
+func (p *SARIFPrinter) revision(repository, commit string) string {
+	key := sarifRevisionKey{repository: NormalizeRepository(repository), commit: commit}
+	idx, ok := p.revisionIndex[key]
+	if !ok {
+		idx = len(p.revisions)
+		p.revisionIndex[key] = idx
+		p.revisions = append(p.revisions, sarifVersionControlDetails{
+			RepositoryURI: key.repository,
+			RevisionID:    key.commit,
+			MappedTo:      sarifArtifactLocation{URIBaseID: sarifRevisionBaseID(idx)},
+		})
+	}
+	return sarifRevisionBaseID(idx)
+}
+
+func sarifRevisionBaseID(idx int) string {
+	return fmt.Sprintf("REVISION%d", idx+1)
+}
+
+// Close writes the collected results as a SARIF log. It must be called once
+// after the scan has finished.
+func (p *SARIFPrinter) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	results := p.results
+	if results == nil {
+		// The schema requires an array, even when nothing was found.
+		results = []sarifResult{}
+	}
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "TruffleHog",
+				InformationURI: "https://github.com/trufflesecurity/trufflehog",
+				Version:        version.BuildVersion,
+				Rules:          p.rules,
+			}},
+			VersionControlProvenance: p.revisions,
+			Results:                  results,
+		}},
+	}
+
+	enc := json.NewEncoder(p.w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(log); err != nil {
+		return fmt.Errorf("could not write SARIF log: %w", err)
+	}
+	return nil
+}
+
+func newSARIFRule(r *detectors.ResultWithMetadata) sarifRule {
+	name := r.DetectorType.String()
+	description := r.DetectorDescription
+	if description == "" {
+		description = fmt.Sprintf("%s secret", name)
+	}
+	rule := sarifRule{
+		ID:               name,
+		Name:             name,
+		ShortDescription: sarifMessage{Text: fmt.Sprintf("%s secret", name)},
+		FullDescription:  sarifMessage{Text: description},
+		Properties:       sarifRuleProperties{Tags: []string{"security", "secret"}},
+	}
+	if rem := remediationOf(r); rem != nil {
+		rule.HelpURI = rem.DocsURL
+		if steps := remediationSteps(rem); steps != "" {
+			rule.Help = &sarifMessage{Text: steps}
+		}
+	}
+	return rule
+}
+
+// sarifLevel maps the verification status of a result to a SARIF level.
+func sarifLevel(r *detectors.ResultWithMetadata) string {
+	switch {
+	case r.Verified:
+		return "error"
+	case r.VerificationError() != nil:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// VerificationStatus returns a human readable verification status: verified,
+// unknown (verification failed) or unverified.
+func VerificationStatus(r *detectors.ResultWithMetadata) string {
+	switch {
+	case r.Verified:
+		return "verified"
+	case r.VerificationError() != nil:
+		return "unknown"
+	default:
+		return "unverified"
+	}
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool                     sarifTool                    `json:"tool"`
+	VersionControlProvenance []sarifVersionControlDetails `json:"versionControlProvenance,omitempty"`
+	Results                  []sarifResult                `json:"results"`
+}
+
+type sarifVersionControlDetails struct {
+	RepositoryURI string                `json:"repositoryUri"`
+	RevisionID    string                `json:"revisionId"`
+	MappedTo      sarifArtifactLocation `json:"mappedTo"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Version        string      `json:"version,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string              `json:"id"`
+	Name             string              `json:"name"`
+	ShortDescription sarifMessage        `json:"shortDescription"`
+	FullDescription  sarifMessage        `json:"fullDescription"`
+	Help             *sarifMessage       `json:"help,omitempty"`
+	HelpURI          string              `json:"helpUri,omitempty"`
+	Properties       sarifRuleProperties `json:"properties"`
+}
+
+type sarifRuleProperties struct {
+	Tags []string `json:"tags"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string                `json:"ruleId"`
+	RuleIndex           int                   `json:"ruleIndex"`
+	Level               string                `json:"level"`
+	Message             sarifMessage          `json:"message"`
+	Locations           []sarifLocation       `json:"locations,omitempty"`
+	PartialFingerprints map[string]string     `json:"partialFingerprints"`
+	Properties          sarifResultProperties `json:"properties"`
+}
+
+type sarifResultProperties struct {
+	Verified          bool   `json:"verified"`
+	VerificationError string `json:"verificationError,omitempty"`
+	Decoder           string `json:"decoder"`
+	Redacted          string `json:"redacted,omitempty"`
+	SourceName        string `json:"sourceName,omitempty"`
+	SourceType        string `json:"sourceType,omitempty"`
+	Commit            string `json:"commit,omitempty"`
+	Repository        string `json:"repository,omitempty"`
+	Email             string `json:"email,omitempty"`
+	Link              string `json:"link,omitempty"`
+
+	Remediation *detectors.Remediation `json:"remediation,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+	ContextRegion    *sarifRegion          `json:"contextRegion,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri,omitempty"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int64         `json:"startLine"`
+	StartColumn int64         `json:"startColumn,omitempty"`
+	EndLine     int64         `json:"endLine,omitempty"`
+	Snippet     *sarifMessage `json:"snippet,omitempty"`
+}
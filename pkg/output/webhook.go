@@ -0,0 +1,432 @@
+package output
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+)
+
+const (
+	// WebhookSignatureHeader carries the hex encoded HMAC-SHA256 of the
+	// WebhookTimestampHeader value and the request body, prefixed with
+	// "sha256=". See SignWebhookBody.
+	WebhookSignatureHeader = "X-TruffleHog-Signature"
+	// WebhookTimestampHeader carries the time the request was sent, in Unix
+	// seconds. It is signed with the body, so receivers can reject replayed
+	// requests by their age.
+	WebhookTimestampHeader = "X-TruffleHog-Timestamp"
+
+	defaultWebhookBatchSize     = 100
+	defaultWebhookFlushInterval = 10 * time.Second
+	defaultWebhookMaxRetries    = 5
+	// webhookQueueSize is the number of batches waiting for the sender
+	// before Print blocks.
+	webhookQueueSize = 16
+
+	webhookSpoolPrefix = "batch-"
+	// webhookDeadLetterDir is the directory in the spool directory where
+	// batches the endpoint rejected are kept. They are not re-sent.
+	webhookDeadLetterDir = "dead-letter"
+)
+
+// WebhookConfig configures a WebhookPrinter.
+type WebhookConfig struct {
+	// URL is the endpoint that batches are POSTed to.
+	URL string
+	// Secret is used to sign each request body. Signing is disabled if empty.
+	Secret string
+	// BatchSize is the maximum number of results per request.
+	BatchSize int
+	// FlushInterval is the maximum time a result waits before being sent.
+	FlushInterval time.Duration
+	// MaxRetries is the number of retries before a batch is spooled.
+	MaxRetries int
+	// SpoolDir is where batches that could not be delivered are stored until
+	// the endpoint is reachable again, and where batches the endpoint
+	// rejected are dead-lettered. Such batches are dropped if empty.
+	SpoolDir string
+	// ContextLines is the number of lines before and after each finding to
+	// send with the secret redacted. Zero disables context.
+	ContextLines int
+	// IncludeSecrets adds the raw secret to each result. It is off by default
+	// so that secrets do not leak into the receiving system.
+	IncludeSecrets bool
+	// Client overrides the HTTP client, mainly for tests.
+	Client *http.Client
+}
+
+// WebhookPrinter is a printer that POSTs results in JSON batches to an HTTP
+// endpoint while the scan is running. Batches are sent by a single sender
+// goroutine, so Print never waits on the network unless the send queue is
+// full. Batches that cannot be delivered after retrying are written to a
+// spool directory. The spool is drained when the printer starts, before each
+// new batch and once more when it is closed. Batches the endpoint rejects
+// with a 4xx status are dead-lettered instead, since re-sending them cannot
+// succeed.
+type WebhookPrinter struct {
+	cfg    WebhookConfig
+	client *http.Client
+	ctx    context.Context
+
+	mu sync.Mutex
+	// cond is signalled when a batch is queued, taken by the sender or the
+	// printer is closed. Its locker is mu.
+	cond    *sync.Cond
+	pending []ResultRecord
+	queue   []webhookBatch
+	seq     int
+	closed  bool
+
+	stop chan struct{}
+	// tickerDone and senderDone are closed when the periodic flush and the
+	// sender have returned.
+	tickerDone chan struct{}
+	senderDone chan struct{}
+	// dropped counts the results of batches that were neither delivered
+	// nor stored. It is only accessed by the sender until it is done.
+	dropped int
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// NewWebhookPrinter creates a WebhookPrinter and starts flushing pending
+// results every FlushInterval. Close must be called to send the remaining
+// results.
+func NewWebhookPrinter(ctx context.Context, cfg WebhookConfig) (*WebhookPrinter, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("webhook URL is required")
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultWebhookBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaultWebhookFlushInterval
+	}
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = defaultWebhookMaxRetries
+	}
+	if cfg.SpoolDir != "" {
+		if err := os.MkdirAll(filepath.Join(cfg.SpoolDir, webhookDeadLetterDir), 0o700); err != nil {
+			return nil, fmt.Errorf("unable to create webhook spool directory: %w", err)
+		}
+	}
+
+	client := cfg.Client
+	if client == nil {
+		client = common.RetryableHTTPClient(
+			common.WithMaxRetries(cfg.MaxRetries),
+			common.WithTimeout(30*time.Second),
+		)
+	}
+
+	p := &WebhookPrinter{
+		cfg:        cfg,
+		client:     client,
+		ctx:        ctx,
+		stop:       make(chan struct{}),
+		tickerDone: make(chan struct{}),
+		senderDone: make(chan struct{}),
+	}
+	p.cond = sync.NewCond(&p.mu)
+	go p.flushPeriodically()
+	go p.sendBatches()
+	return p, nil
+}
+
+func (p *WebhookPrinter) Print(_ context.Context, r *detectors.ResultWithMetadata) error {
+	res, err := NewResultRecord(r, RecordOptions{
+		IncludeSecrets: p.cfg.IncludeSecrets,
+		ContextLines:   p.cfg.ContextLines,
+	})
+	if err != nil {
+		return fmt.Errorf("could not marshal result: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return errors.New("webhook printer is closed")
+	}
+
+	p.pending = append(p.pending, res)
+	if len(p.pending) < p.cfg.BatchSize {
+		return nil
+	}
+	p.enqueueLocked()
+	// Slow Print down to the sender's pace. Waiting releases p.mu, so other
+	// callers and the periodic flush are not blocked meanwhile.
+	for len(p.queue) > webhookQueueSize && !p.closed {
+		p.cond.Wait()
+	}
+	return nil
+}
+
+// Close stops the periodic flush, sends all pending results and waits for
+// the sender to finish. It returns an error if any results were dropped.
+// Calling Close more than once returns the first result.
+func (p *WebhookPrinter) Close() error {
+	p.closeOnce.Do(func() {
+		close(p.stop)
+		<-p.tickerDone
+
+		p.mu.Lock()
+		p.enqueueLocked()
+		p.closed = true
+		p.cond.Broadcast()
+		p.mu.Unlock()
+
+		<-p.senderDone
+		if p.dropped > 0 {
+			p.closeErr = fmt.Errorf("dropped %d webhook results", p.dropped)
+		}
+	})
+	return p.closeErr
+}
+
+func (p *WebhookPrinter) flushPeriodically() {
+	defer close(p.tickerDone)
+	ticker := time.NewTicker(p.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.mu.Lock()
+			p.enqueueLocked()
+			p.mu.Unlock()
+		}
+	}
+}
+
+// enqueueLocked hands the pending results to the sender as a batch. It never
+// blocks. p.mu must be held.
+func (p *WebhookPrinter) enqueueLocked() {
+	if len(p.pending) == 0 {
+		return
+	}
+	p.seq++
+	p.queue = append(p.queue, webhookBatch{
+		BatchID: fmt.Sprintf("%d-%d", time.Now().UnixNano(), p.seq),
+		Results: p.pending,
+	})
+	p.pending = nil
+	p.cond.Broadcast()
+}
+
+// nextBatch waits for the next queued batch. It returns false once the
+// printer is closed and the queue is empty.
+func (p *WebhookPrinter) nextBatch() (webhookBatch, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for len(p.queue) == 0 && !p.closed {
+		p.cond.Wait()
+	}
+	if len(p.queue) == 0 {
+		return webhookBatch{}, false
+	}
+	batch := p.queue[0]
+	p.queue = p.queue[1:]
+	p.cond.Broadcast()
+	return batch, true
+}
+
+// sendBatches delivers queued batches until the printer is closed. Batches
+// spooled by an earlier run are sent first, and the spool is drained once
+// more at the end so that batches spooled during an outage are not left
+// behind when the endpoint has recovered.
+func (p *WebhookPrinter) sendBatches() {
+	defer close(p.senderDone)
+	if err := p.drainSpool(p.ctx); err != nil {
+		p.ctx.Logger().Error(err, "error sending spooled webhook results")
+	}
+	for {
+		batch, ok := p.nextBatch()
+		if !ok {
+			break
+		}
+		if err := p.deliver(batch); err != nil {
+			p.ctx.Logger().Error(err, "error delivering webhook results", "batch_id", batch.BatchID)
+		}
+	}
+	if err := p.drainSpool(p.ctx); err != nil {
+		p.ctx.Logger().Error(err, "error sending spooled webhook results, they remain in the spool")
+	}
+}
+
+// deliver sends spooled batches first, so the receiver sees results in the
+// order they were found, and then batch.
+func (p *WebhookPrinter) deliver(batch webhookBatch) error {
+	batch.SentAt = time.Now().UTC()
+	body, err := json.Marshal(batch)
+	if err != nil {
+		p.dropped += len(batch.Results)
+		return fmt.Errorf("could not marshal webhook batch: %w", err)
+	}
+
+	// Keep ordering intact: never send a new batch while older ones are
+	// still waiting in the spool.
+	spoolErr := p.drainSpool(p.ctx)
+	if spoolErr == nil {
+		if err = p.send(p.ctx, body); err == nil {
+			return nil
+		}
+	} else {
+		err = spoolErr
+	}
+
+	var statusErr *webhookStatusError
+	rejected := spoolErr == nil && errors.As(err, &statusErr) && statusErr.permanent()
+	switch {
+	case p.cfg.SpoolDir == "":
+		p.dropped += len(batch.Results)
+		return fmt.Errorf("dropped %d webhook results: %w", len(batch.Results), err)
+	case rejected:
+		if err := p.spool(webhookDeadLetterDir, batch.BatchID, body); err != nil {
+			p.dropped += len(batch.Results)
+			return err
+		}
+		return fmt.Errorf("webhook endpoint rejected batch, moved it to the dead-letter directory: %w", err)
+	default:
+		if spoolErr := p.spool("", batch.BatchID, body); spoolErr != nil {
+			p.dropped += len(batch.Results)
+			return errors.Join(err, spoolErr)
+		}
+		p.ctx.Logger().Info("webhook endpoint unavailable, spooled batch", "batch_id", batch.BatchID, "error", err.Error())
+		return nil
+	}
+}
+
+// webhookStatusError is returned for a response with a non-2xx status.
+type webhookStatusError struct{ code int }
+
+func (e *webhookStatusError) Error() string {
+	return fmt.Sprintf("webhook returned status %d", e.code)
+}
+
+// permanent reports whether re-sending the same batch cannot succeed.
+func (e *webhookStatusError) permanent() bool {
+	return e.code >= 400 && e.code < 500 &&
+		e.code != http.StatusRequestTimeout && e.code != http.StatusTooManyRequests
+}
+
+func (p *WebhookPrinter) send(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.cfg.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(WebhookTimestampHeader, timestamp)
+		req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhookBody([]byte(p.cfg.Secret), timestamp, body))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &webhookStatusError{code: resp.StatusCode}
+	}
+	return nil
+}
+
+// spool writes body to dir, relative to the spool directory.
+func (p *WebhookPrinter) spool(dir, batchID string, body []byte) error {
+	name := filepath.Join(p.cfg.SpoolDir, dir, webhookSpoolPrefix+batchID+".json")
+	if err := os.WriteFile(name, body, 0o600); err != nil {
+		return fmt.Errorf("unable to spool webhook batch: %w", err)
+	}
+	return nil
+}
+
+// drainSpool re-sends spooled batches oldest first. Batches the endpoint
+// rejects are moved to the dead-letter directory, so that one bad batch
+// cannot block delivery forever. Any other failure stops the drain, since the
+// endpoint is likely still unavailable.
+func (p *WebhookPrinter) drainSpool(ctx context.Context) error {
+	if p.cfg.SpoolDir == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(p.cfg.SpoolDir)
+	if err != nil {
+		return fmt.Errorf("unable to read webhook spool: %w", err)
+	}
+
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasPrefix(e.Name(), webhookSpoolPrefix) {
+			names = append(names, e.Name())
+		}
+	}
+	// Batch IDs start with a timestamp, so lexical order is send order for
+	// timestamps of the same width.
+	sort.Strings(names)
+
+	for _, name := range names {
+		path := filepath.Join(p.cfg.SpoolDir, name)
+		body, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("unable to read spooled webhook batch: %w", err)
+		}
+		err = p.send(ctx, body)
+		var statusErr *webhookStatusError
+		switch {
+		case err == nil:
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("unable to remove spooled webhook batch: %w", err)
+			}
+		case errors.As(err, &statusErr) && statusErr.permanent():
+			ctx.Logger().Error(err, "webhook endpoint rejected spooled batch, moving it to the dead-letter directory", "file", name)
+			if err := os.Rename(path, filepath.Join(p.cfg.SpoolDir, webhookDeadLetterDir, name)); err != nil {
+				return fmt.Errorf("unable to dead-letter spooled webhook batch: %w", err)
+			}
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// SignWebhookBody returns the hex encoded HMAC-SHA256 of timestamp, a ".", and
+// body. Receivers can use it with the WebhookTimestampHeader value to verify
+// the WebhookSignatureHeader, and should reject requests whose timestamp is
+// too old.
+func SignWebhookBody(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+type webhookBatch struct {
+	BatchID string         `json:"batch_id"`
+	SentAt  time.Time      `json:"sent_at"`
+	Results []ResultRecord `json:"results"`
+}
@@ -0,0 +1,221 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/output/outputtest"
+)
+
+// webhookTestClient retries like the default client, without the long waits.
+func webhookTestClient() *http.Client {
+	return common.RetryableHTTPClient(
+		common.WithMaxRetries(2),
+		common.WithRetryWaitMin(time.Millisecond),
+		common.WithRetryWaitMax(5*time.Millisecond),
+	)
+}
+
+func TestWebhookPrinter(t *testing.T) {
+	ctx := context.Background()
+	secret := "s3cr3t"
+
+	var (
+		mu       sync.Mutex
+		received []webhookBatch
+		down     atomic.Bool
+		attempts atomic.Int32
+		flaky    atomic.Int32
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		if down.Load() || flaky.Add(-1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		timestamp := r.Header.Get(WebhookTimestampHeader)
+		assert.NotEmpty(t, timestamp)
+		assert.Equal(t, "sha256="+SignWebhookBody([]byte(secret), timestamp, body), r.Header.Get(WebhookSignatureHeader))
+
+		var batch webhookBatch
+		assert.NoError(t, json.Unmarshal(body, &batch))
+		mu.Lock()
+		received = append(received, batch)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	spoolDir := t.TempDir()
+	p, err := NewWebhookPrinter(ctx, WebhookConfig{
+		URL:           server.URL,
+		Secret:        secret,
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+		SpoolDir:      spoolDir,
+		Client:        webhookTestClient(),
+	})
+	require.NoError(t, err)
+
+	// The endpoint is down: the full batch is spooled after retrying.
+	down.Store(true)
+	require.NoError(t, p.Print(ctx, outputtest.GitResult("a", "repo", "a.txt", "c1", 1, false)))
+	require.NoError(t, p.Print(ctx, outputtest.GitResult("b", "repo", "b.txt", "c1", 2, true)))
+	assert.Eventually(t, func() bool { return len(spooledBatches(t, spoolDir)) == 1 }, 5*time.Second, 5*time.Millisecond)
+	assert.Equal(t, int32(3), attempts.Load())
+
+	// Once the endpoint is back, the spooled batch is sent before new ones.
+	// A single failure is absorbed by the client's retries.
+	down.Store(false)
+	flaky.Store(1)
+	require.NoError(t, p.Print(ctx, outputtest.GitResult("c", "repo", "c.txt", "c1", 3, false)))
+	require.NoError(t, p.Close())
+	require.NoError(t, p.Close())
+	assert.Error(t, p.Print(ctx, outputtest.GitResult("d", "repo", "d.txt", "c1", 4, false)))
+
+	assert.Empty(t, spooledBatches(t, spoolDir))
+	require.Len(t, received, 2)
+	assert.Len(t, received[0].Results, 2)
+	assert.Equal(t, "verified", received[0].Results[1].Status)
+	assert.Empty(t, received[0].Results[1].Raw)
+	assert.Len(t, received[1].Results, 1)
+}
+
+func TestWebhookPrinter_DeadLetter(t *testing.T) {
+	ctx := context.Background()
+
+	var (
+		mu       sync.Mutex
+		received int
+		reject   atomic.Bool
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		if reject.Load() || !json.Valid(body) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		received++
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	spoolDir := t.TempDir()
+	// A batch spooled by an earlier run that the endpoint rejects must not
+	// block the batches after it.
+	require.NoError(t, os.WriteFile(filepath.Join(spoolDir, webhookSpoolPrefix+"0-0.json"), []byte(`{"bad"`), 0o600))
+
+	p, err := NewWebhookPrinter(ctx, WebhookConfig{
+		URL:           server.URL,
+		BatchSize:     1,
+		FlushInterval: time.Hour,
+		SpoolDir:      spoolDir,
+		Client:        webhookTestClient(),
+	})
+	require.NoError(t, err)
+
+	reject.Store(true)
+	require.NoError(t, p.Print(ctx, outputtest.GitResult("a", "repo", "a.txt", "c1", 1, false)))
+	assert.Eventually(t, func() bool {
+		entries, err := os.ReadDir(filepath.Join(spoolDir, webhookDeadLetterDir))
+		return err == nil && len(entries) == 2
+	}, 5*time.Second, 5*time.Millisecond)
+	assert.Empty(t, spooledBatches(t, spoolDir))
+
+	reject.Store(false)
+	require.NoError(t, p.Print(ctx, outputtest.GitResult("b", "repo", "b.txt", "c1", 2, false)))
+	require.NoError(t, p.Close())
+	assert.Equal(t, 1, received)
+}
+
+func TestWebhookPrinter_DrainsSpoolOnStart(t *testing.T) {
+	ctx := context.Background()
+
+	var received atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+	}))
+	defer server.Close()
+
+	spoolDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(spoolDir, webhookSpoolPrefix+"0-0.json"), []byte(`{"results":[]}`), 0o600))
+
+	p, err := NewWebhookPrinter(ctx, WebhookConfig{
+		URL:           server.URL,
+		FlushInterval: time.Hour,
+		SpoolDir:      spoolDir,
+		Client:        webhookTestClient(),
+	})
+	require.NoError(t, err)
+
+	// Batches left by an earlier run are sent without waiting for new results.
+	assert.Eventually(t, func() bool { return received.Load() == 1 }, 5*time.Second, 5*time.Millisecond)
+	assert.Empty(t, spooledBatches(t, spoolDir))
+	require.NoError(t, p.Close())
+}
+
+func TestWebhookPrinter_DrainsSpoolOnClose(t *testing.T) {
+	ctx := context.Background()
+
+	var (
+		received atomic.Int32
+		down     atomic.Bool
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		received.Add(1)
+	}))
+	defer server.Close()
+
+	spoolDir := t.TempDir()
+	p, err := NewWebhookPrinter(ctx, WebhookConfig{
+		URL:           server.URL,
+		BatchSize:     1,
+		FlushInterval: time.Hour,
+		SpoolDir:      spoolDir,
+		Client:        webhookTestClient(),
+	})
+	require.NoError(t, err)
+
+	down.Store(true)
+	require.NoError(t, p.Print(ctx, outputtest.GitResult("a", "repo", "a.txt", "c1", 1, false)))
+	assert.Eventually(t, func() bool { return len(spooledBatches(t, spoolDir)) == 1 }, 5*time.Second, 5*time.Millisecond)
+
+	// No new batch follows, so only Close can send the spooled one.
+	down.Store(false)
+	require.NoError(t, p.Close())
+	assert.Equal(t, int32(1), received.Load())
+	assert.Empty(t, spooledBatches(t, spoolDir))
+}
+
+func spooledBatches(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	return names
+}
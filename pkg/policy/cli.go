@@ -0,0 +1,23 @@
+package policy
+
+import (
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+var policyFile *string
+
+// Flags registers the policy flag on app.
+func Flags(app *kingpin.Application) {
+	policyFile = app.Flag("policy", "Ignore findings matched by the rules in this YAML policy file.").String()
+}
+
+// FromFlags loads the policy given by --policy. It returns nil if the flag is
+// not set.
+func FromFlags(ctx context.Context) (*Policy, error) {
+	if *policyFile == "" {
+		return nil, nil
+	}
+	return Load(ctx, *policyFile)
+}
@@ -0,0 +1,218 @@
+// Package policy implements ignore rules that are kept in a reviewed YAML
+// file instead of inline comments. Every rule must state why it exists and
+// may expire, after which it no longer suppresses anything.
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gobwas/glob"
+	"gopkg.in/yaml.v2"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/output"
+)
+
+// File is the on-disk representation of a policy.
+//
+//	rules:
+//	  - reason: Test fixture, key was revoked in INC-1234.
+//	    detector: AWS
+//	    path: "testdata/**"
+//	    expires: 2025-06-30
+type File struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Expiry is the time from which a rule no longer applies. In YAML it is either
+// a date, which is inclusive so the rule applies until the end of that day in
+// UTC, or an RFC 3339 time.
+type Expiry struct {
+	time.Time
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (e *Expiry) UnmarshalYAML(unmarshal func(any) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		e.Time = t.AddDate(0, 0, 1)
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return fmt.Errorf("invalid expiry %q: expected a date (YYYY-MM-DD) or an RFC 3339 time", s)
+	}
+	e.Time = t
+	return nil
+}
+
+// Rule ignores the findings that match all of its non-empty criteria.
+type Rule struct {
+	// Reason is mandatory and explains why the findings may be ignored.
+	Reason string `yaml:"reason"`
+	// Expires is the time from which the rule no longer applies. It is
+	// checked for every finding, so long running scans stop applying the rule
+	// once it expires.
+	Expires *Expiry `yaml:"expires,omitempty"`
+
+	Detector    string `yaml:"detector,omitempty"`
+	Path        string `yaml:"path,omitempty"`
+	Repository  string `yaml:"repository,omitempty"`
+	SecretHash  string `yaml:"secret_hash,omitempty"`
+	Fingerprint string `yaml:"fingerprint,omitempty"`
+
+	// path matches slash separated paths, so "*" stays within a directory
+	// and "**" crosses directories.
+	path glob.Glob
+}
+
+// Policy is a compiled set of ignore rules.
+type Policy struct {
+	rules []*Rule
+	now   func() time.Time
+}
+
+// Load reads and validates a policy file. Expired rules are kept but reported
+// with a warning, so that they show up in the scan log until removed.
+func Load(ctx context.Context, filename string) (*Policy, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read policy: %w", err)
+	}
+
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("unable to parse policy %s: %w", filename, err)
+	}
+
+	p, err := New(f.Rules)
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy %s: %w", filename, err)
+	}
+	for i, rule := range p.rules {
+		if rule.expired(p.now()) {
+			ctx.Logger().Info("ignore rule has expired and no longer applies",
+				"policy", filename, "rule", i+1, "reason", rule.Reason, "expired", rule.Expires.Format(time.RFC3339))
+		}
+	}
+	return p, nil
+}
+
+// New validates and compiles a list of rules.
+func New(rules []Rule) (*Policy, error) {
+	p := &Policy{now: time.Now}
+	var errs []error
+	for i := range rules {
+		rule := rules[i]
+		if err := rule.compile(); err != nil {
+			errs = append(errs, fmt.Errorf("rule %d: %w", i+1, err))
+			continue
+		}
+		p.rules = append(p.rules, &rule)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return p, nil
+}
+
+func (r *Rule) compile() error {
+	if strings.TrimSpace(r.Reason) == "" {
+		return errors.New("reason is required")
+	}
+	if r.Detector == "" && r.Path == "" && r.Repository == "" && r.SecretHash == "" && r.Fingerprint == "" {
+		return errors.New("at least one of detector, path, repository, secret_hash or fingerprint is required")
+	}
+	if r.Path != "" {
+		g, err := glob.Compile(r.Path, '/')
+		if err != nil {
+			return fmt.Errorf("invalid path glob %q: %w", r.Path, err)
+		}
+		r.path = g
+	}
+	return nil
+}
+
+func (r *Rule) expired(now time.Time) bool {
+	return r.Expires != nil && !now.Before(r.Expires.Time)
+}
+
+func (r *Rule) matches(res *detectors.ResultWithMetadata, loc output.ResultLocation) bool {
+	if r.Detector != "" &&
+		!strings.EqualFold(r.Detector, res.DetectorType.String()) &&
+		!strings.EqualFold(r.Detector, res.DetectorName) {
+		return false
+	}
+	if r.path != nil && (loc.File == "" || !r.path.Match(output.NormalizePath(loc.File))) {
+		return false
+	}
+	if r.Repository != "" && output.NormalizeRepository(r.Repository) != output.NormalizeRepository(loc.Repository) {
+		return false
+	}
+	if r.SecretHash != "" && !strings.EqualFold(r.SecretHash, output.SecretHash(res)) {
+		return false
+	}
+	if r.Fingerprint != "" {
+		fingerprint, err := output.Fingerprint(res)
+		if err != nil || !strings.EqualFold(r.Fingerprint, fingerprint) {
+			return false
+		}
+	}
+	return true
+}
+
+// Match returns the first unexpired rule that ignores the result, or nil.
+func (p *Policy) Match(r *detectors.ResultWithMetadata) (*Rule, error) {
+	loc, err := output.LocationOf(r)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read result metadata: %w", err)
+	}
+	now := p.now()
+	for _, rule := range p.rules {
+		if rule.expired(now) {
+			continue
+		}
+		if rule.matches(r, loc) {
+			return rule, nil
+		}
+	}
+	return nil, nil
+}
+
+// Filter is a printer that drops results ignored by a policy and passes
+// everything else to the next printer.
+type Filter struct {
+	policy  *Policy
+	next    output.Printer
+	ignored atomic.Uint64
+}
+
+// NewFilter creates a Filter in front of next.
+func NewFilter(p *Policy, next output.Printer) *Filter {
+	return &Filter{policy: p, next: next}
+}
+
+func (f *Filter) Print(ctx context.Context, r *detectors.ResultWithMetadata) error {
+	rule, err := f.policy.Match(r)
+	if err != nil {
+		return err
+	}
+	if rule != nil {
+		f.ignored.Add(1)
+		ctx.Logger().V(3).Info("result ignored by policy", "detector", r.DetectorType.String(), "reason", rule.Reason)
+		return nil
+	}
+	return f.next.Print(ctx, r)
+}
+
+// Ignored returns the number of results dropped so far.
+func (f *Filter) Ignored() uint64 { return f.ignored.Load() }
@@ -0,0 +1,144 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/output"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/output/outputtest"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+)
+
+func testResult(detector detectorspb.DetectorType, raw, repo, file string) *detectors.ResultWithMetadata {
+	r := outputtest.GitResult(raw, repo, file, "abc123", 1, false)
+	r.DetectorType = detector
+	return r
+}
+
+func TestPolicy_Match(t *testing.T) {
+	fixture := testResult(detectorspb.DetectorType_AWS, "secret", "https://github.com/org/repo.git", "testdata/keys.txt")
+	fingerprint, err := output.Fingerprint(fixture)
+	require.NoError(t, err)
+	expired := &Expiry{time.Now().Add(-time.Hour)}
+
+	tests := []struct {
+		name    string
+		rule    Rule
+		ignored bool
+	}{
+		{name: "detector", rule: Rule{Detector: "aws"}, ignored: true},
+		{name: "other detector", rule: Rule{Detector: "Slack"}},
+		{name: "path glob", rule: Rule{Path: "testdata/*"}, ignored: true},
+		{name: "path glob stays in directory", rule: Rule{Path: "*.txt"}},
+		{name: "path glob crosses directories", rule: Rule{Path: "**.txt"}, ignored: true},
+		{name: "other path", rule: Rule{Path: "src/*"}},
+		{name: "repository", rule: Rule{Repository: "https://github.com/org/repo"}, ignored: true},
+		{name: "secret hash", rule: Rule{SecretHash: output.SecretHash(fixture)}, ignored: true},
+		{name: "fingerprint", rule: Rule{Fingerprint: fingerprint}, ignored: true},
+		{name: "all criteria must match", rule: Rule{Detector: "AWS", Path: "src/*"}},
+		{name: "expired", rule: Rule{Detector: "AWS", Expires: expired}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.rule.Reason = "test"
+			p, err := New([]Rule{tt.rule})
+			require.NoError(t, err)
+
+			rule, err := p.Match(fixture)
+			require.NoError(t, err)
+			assert.Equal(t, tt.ignored, rule != nil)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "valid.yaml")
+	require.NoError(t, os.WriteFile(valid, []byte(`
+rules:
+  - reason: Test fixtures.
+    path: "testdata/*"
+    expires: 2999-01-01
+`), 0o644))
+	p, err := Load(ctx, valid)
+	require.NoError(t, err)
+	require.Len(t, p.rules, 1)
+	assert.Equal(t, 2999, p.rules[0].Expires.Year())
+
+	missingReason := filepath.Join(dir, "missing-reason.yaml")
+	require.NoError(t, os.WriteFile(missingReason, []byte(`
+rules:
+  - detector: AWS
+`), 0o644))
+	_, err = Load(ctx, missingReason)
+	assert.ErrorContains(t, err, "reason is required")
+}
+
+func TestPolicy_PathGlobSeparator(t *testing.T) {
+	p, err := New([]Rule{{Reason: "test", Path: "testdata/*"}})
+	require.NoError(t, err)
+
+	rule, err := p.Match(testResult(detectorspb.DetectorType_AWS, "secret", "repo", "testdata/deep/keys.txt"))
+	require.NoError(t, err)
+	assert.Nil(t, rule, "* must not cross directories")
+
+	p, err = New([]Rule{{Reason: "test", Path: "testdata/**"}})
+	require.NoError(t, err)
+	rule, err = p.Match(testResult(detectorspb.DetectorType_AWS, "secret", "repo", "testdata/deep/keys.txt"))
+	require.NoError(t, err)
+	assert.NotNil(t, rule)
+}
+
+func TestPolicy_ExpiryDateIsInclusive(t *testing.T) {
+	var f File
+	require.NoError(t, yaml.Unmarshal([]byte(`
+rules:
+  - reason: Test fixtures.
+    detector: AWS
+    expires: 2025-06-30
+  - reason: Rotated key.
+    detector: AWS
+    expires: "2025-06-30T12:00:00Z"
+`), &f))
+	fixture := testResult(detectorspb.DetectorType_AWS, "secret", "repo", "keys.txt")
+
+	tests := []struct {
+		name   string
+		now    time.Time
+		reason string
+	}{
+		{name: "before", now: time.Date(2025, 6, 29, 12, 0, 0, 0, time.UTC), reason: "Test fixtures."},
+		{name: "last day", now: time.Date(2025, 6, 30, 23, 59, 0, 0, time.UTC), reason: "Test fixtures."},
+		{name: "day after", now: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(f.Rules)
+			require.NoError(t, err)
+			p.now = func() time.Time { return tt.now }
+
+			rule, err := p.Match(fixture)
+			require.NoError(t, err)
+			if tt.reason == "" {
+				assert.Nil(t, rule)
+				return
+			}
+			require.NotNil(t, rule)
+			assert.Equal(t, tt.reason, rule.Reason)
+		})
+	}
+
+	var invalid File
+	assert.Error(t, yaml.Unmarshal([]byte("rules:\n  - expires: next week\n"), &invalid))
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+var (
+	listenAddr      *string
+	token           *string
+	insecureNoToken *bool
+	includeSecrets  *bool
+	allowedRoots    *[]string
+	jobTTL          *time.Duration
+)
+
+// Command registers the serve subcommand and its flags on app.
+func Command(app *kingpin.Application) *kingpin.CmdClause {
+	cli := app.Command("serve", "Run a scan server that accepts jobs over HTTP.")
+	listenAddr = cli.Flag("listen", "Address to serve the HTTP API on.").Default("127.0.0.1:8080").String()
+	token = cli.Flag("token", "Bearer token required on every request.").Envar("TRUFFLEHOG_SERVE_TOKEN").String()
+	insecureNoToken = cli.Flag("insecure-no-token", "Serve the API without a token. Anyone who can reach the server can run scans.").Bool()
+	includeSecrets = cli.Flag("include-secrets", "Include raw secrets in results. Results are redacted by default.").Bool()
+	allowedRoots = cli.Flag("allowed-root", "Local directory that filesystem and git jobs may scan. Can be repeated. Without any, local paths are rejected.").Strings()
+	jobTTL = cli.Flag("job-ttl", "How long finished jobs and their results are kept.").Default(defaultJobTTL.String()).Duration()
+	return cli
+}
+
+// ConfigFromFlags returns cfg with the values of the serve flags applied.
+func ConfigFromFlags(cfg Config) Config {
+	cfg.Token = *token
+	cfg.Insecure = *insecureNoToken
+	cfg.IncludeSecrets = *includeSecrets
+	cfg.AllowedRoots = *allowedRoots
+	cfg.JobTTL = *jobTTL
+	return cfg
+}
+
+// ListenAndServe serves the HTTP API of s on the address given by --listen
+// until ctx is cancelled, then shuts down gracefully.
+func ListenAndServe(ctx context.Context, s *Server) error {
+	srv := &http.Server{
+		Addr:              *listenAddr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		ctx.Logger().Info("scan server listening", "address", srv.Addr)
+		errCh <- srv.ListenAndServe()
+	}()
+
+	var serveErr error
+	select {
+	case serveErr = <-errCh:
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	if errors.Is(serveErr, http.ErrServerClosed) {
+		return nil
+	}
+	return serveErr
+}
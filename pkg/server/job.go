@@ -0,0 +1,163 @@
+package server
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/output"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+var errCancelled = errors.New("cancelled by user")
+
+// job tracks a submitted scan and buffers its results until the client
+// has fetched them.
+type job struct {
+	id         sources.JobID
+	name       string
+	sourceType sourcespb.SourceType
+
+	mu        sync.Mutex
+	ref       *sources.JobProgressRef
+	cancelled bool
+	results   []output.ResultRecord
+	// updated is closed and replaced whenever a result is added, waking up
+	// every stream waiting for it.
+	updated chan struct{}
+	// finishedAt is when the server first saw the job done. It is set by
+	// expired and used to evict the job once its TTL has passed.
+	finishedAt time.Time
+}
+
+func newJob(id sources.JobID, name string, sourceType sourcespb.SourceType) *job {
+	return &job{id: id, name: name, sourceType: sourceType, updated: make(chan struct{})}
+}
+
+func (j *job) setRef(ref sources.JobProgressRef) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.ref = &ref
+}
+
+func (j *job) addResult(rec output.ResultRecord) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.results = append(j.results, rec)
+	close(j.updated)
+	j.updated = make(chan struct{})
+}
+
+// resultsSince returns the results starting at index next, a channel that is
+// closed when more results arrive and whether the job is done.
+func (j *job) resultsSince(next int) ([]output.ResultRecord, <-chan struct{}, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	var results []output.ResultRecord
+	if next < len(j.results) {
+		results = j.results[next:]
+	}
+	return results, j.updated, j.isDone()
+}
+
+// done returns a channel that is closed when the job's source has finished.
+func (j *job) done() <-chan struct{} {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.ref == nil {
+		return nil
+	}
+	return j.ref.Done()
+}
+
+// isDone must be called with j.mu held.
+func (j *job) isDone() bool {
+	if j.ref == nil {
+		return false
+	}
+	select {
+	case <-j.ref.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+// expired reports whether the job has been done for longer than ttl at now.
+func (j *job) expired(now time.Time, ttl time.Duration) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if !j.isDone() {
+		return false
+	}
+	if j.finishedAt.IsZero() {
+		j.finishedAt = now
+	}
+	return now.Sub(j.finishedAt) >= ttl
+}
+
+func (j *job) cancel() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.ref == nil || j.cancelled {
+		return
+	}
+	j.cancelled = true
+	j.ref.CancelRun(errCancelled)
+}
+
+// JobStatus is the API representation of a job.
+type JobStatus struct {
+	JobID           int64      `json:"job_id"`
+	Name            string     `json:"name"`
+	SourceType      string     `json:"source_type"`
+	State           string     `json:"state"`
+	PercentComplete int        `json:"percent_complete"`
+	TotalUnits      uint64     `json:"total_units"`
+	FinishedUnits   uint64     `json:"finished_units"`
+	TotalChunks     uint64     `json:"total_chunks"`
+	Results         int        `json:"results"`
+	Errors          []string   `json:"errors,omitempty"`
+	StartTime       *time.Time `json:"start_time,omitempty"`
+	EndTime         *time.Time `json:"end_time,omitempty"`
+}
+
+func (j *job) status() JobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	st := JobStatus{
+		JobID:      int64(j.id),
+		Name:       j.name,
+		SourceType: j.sourceType.String(),
+		State:      "pending",
+		Results:    len(j.results),
+	}
+	if j.ref == nil {
+		return st
+	}
+
+	snap := j.ref.Snapshot()
+	st.PercentComplete = snap.PercentComplete()
+	st.TotalUnits = snap.TotalUnits
+	st.FinishedUnits = snap.FinishedUnits
+	st.TotalChunks = snap.TotalChunks
+	st.StartTime = snap.StartTime
+	st.EndTime = snap.EndTime
+	for _, err := range snap.Errors {
+		st.Errors = append(st.Errors, err.Error())
+	}
+
+	switch {
+	case j.isDone() && j.cancelled:
+		st.State = "cancelled"
+	case j.isDone():
+		st.State = "done"
+	case j.cancelled:
+		st.State = "cancelling"
+	default:
+		st.State = "running"
+	}
+	return st
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+)
+
+var errPathNotAllowed = errors.New("path is outside of the allowed scan roots")
+
+// scanRoots are the local directories that jobs may read from. Paths are
+// compared after resolving symlinks, so a link inside a root cannot be used
+// to escape it.
+type scanRoots []string
+
+func newScanRoots(dirs []string) (scanRoots, error) {
+	roots := make(scanRoots, 0, len(dirs))
+	for _, dir := range dirs {
+		resolved, err := resolvePath(dir)
+		if err != nil {
+			return nil, fmt.Errorf("invalid scan root %q: %w", dir, err)
+		}
+		roots = append(roots, resolved)
+	}
+	return roots, nil
+}
+
+// check returns an error if any path is outside of the roots.
+func (r scanRoots) check(paths []string) error {
+	for _, path := range paths {
+		if !r.contains(path) {
+			return fmt.Errorf("%w: %s", errPathNotAllowed, path)
+		}
+	}
+	return nil
+}
+
+func (r scanRoots) contains(path string) bool {
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return false
+	}
+	for _, root := range r {
+		rel, err := filepath.Rel(root, resolved)
+		if err != nil {
+			continue
+		}
+		if rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(abs)
+}
+
+// localPaths returns the local files and directories that a source created
+// from conn would read. Only sources that read from the local disk return
+// any paths.
+func localPaths(conn *anypb.Any) ([]string, error) {
+	if conn.GetTypeUrl() == "" {
+		return nil, nil
+	}
+	msg, err := conn.UnmarshalNew()
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	switch c := msg.(type) {
+	case *sourcespb.Filesystem:
+		paths = append(paths, c.GetPaths()...)
+		paths = append(paths, c.GetDirectories()...)
+		paths = append(paths, c.GetIncludePathsFile(), c.GetExcludePathsFile())
+	case *sourcespb.Git:
+		paths = append(paths, c.GetDirectories()...)
+		for _, uri := range append(c.GetRepositories(), c.GetUri()) {
+			if path, ok := localRepoPath(uri); ok {
+				paths = append(paths, path)
+			}
+		}
+		paths = append(paths, c.GetIncludePathsFile(), c.GetExcludePathsFile())
+	}
+
+	nonEmpty := paths[:0]
+	for _, path := range paths {
+		if path != "" {
+			nonEmpty = append(nonEmpty, path)
+		}
+	}
+	return nonEmpty, nil
+}
+
+// localRepoPath returns the path of a git repository URI that refers to the
+// local disk, either with the file scheme or as a plain path.
+func localRepoPath(uri string) (string, bool) {
+	if uri == "" {
+		return "", false
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		// scp-like remotes such as git@github.com:org/repo do not parse.
+		return "", false
+	}
+	switch u.Scheme {
+	case "file":
+		return u.Path, true
+	case "":
+		return uri, true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,420 @@
+// Package server exposes a long-running scan service over HTTP. Jobs
+// are run by a shared sources.SourceManager, so detectors are loaded once and
+// reused across scans.
+package server
+
+import (
+	stdcontext "context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/output"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+const (
+	// defaultResultsGrace is how long a results stream stays open after its
+	// source finished, because detection of the last chunks can still be
+	// running.
+	defaultResultsGrace = 5 * time.Second
+	// defaultJobTTL is how long a finished job and its results are kept.
+	defaultJobTTL = time.Hour
+	// maxSubmitBodySize caps the size of a job submission. Source
+	// connections are small, so anything larger is rejected before it is
+	// decoded.
+	maxSubmitBodySize = 1 << 20
+)
+
+// SourceFactory creates an uninitialized source of a given type.
+type SourceFactory func() sources.Source
+
+// Config configures a Server.
+type Config struct {
+	// Manager runs the submitted jobs. Its chunks must be consumed by an
+	// engine whose printer is the Server.
+	Manager *sources.SourceManager
+	// Sources maps the source types that may be submitted to their factory.
+	Sources map[sourcespb.SourceType]SourceFactory
+	// Concurrency is passed to each source's Init.
+	Concurrency int
+	// ResultsGrace overrides how long result streams wait for late results
+	// after a job finished.
+	ResultsGrace time.Duration
+	// Token must be sent as a bearer token with every request. New refuses
+	// to create a server without a token unless Insecure is set.
+	Token string
+	// Insecure allows serving the API without a token.
+	Insecure bool
+	// IncludeSecrets adds the raw secrets to the results. Results are
+	// redacted by default.
+	IncludeSecrets bool
+	// AllowedRoots are the local directories that filesystem and git jobs
+	// may scan. Jobs reading any local path outside of them are rejected, so
+	// without roots only remote sources can be scanned.
+	AllowedRoots []string
+	// JobTTL is how long a finished job and its results are kept before the
+	// job is evicted.
+	JobTTL time.Duration
+}
+
+// Server accepts scan jobs over HTTP and is the printer of the engine that
+// processes them, so it can route results back to the job they belong to.
+type Server struct {
+	cfg   Config
+	ctx   context.Context
+	roots scanRoots
+
+	mu   sync.RWMutex
+	jobs map[sources.JobID]*job
+}
+
+// New creates a Server. ctx is used for running jobs, so cancelling it stops
+// all of them.
+func New(ctx context.Context, cfg Config) (*Server, error) {
+	if cfg.Manager == nil {
+		return nil, errors.New("a source manager is required")
+	}
+	if cfg.Token == "" && !cfg.Insecure {
+		return nil, errors.New("a token is required to serve the API without authentication")
+	}
+	if cfg.ResultsGrace <= 0 {
+		cfg.ResultsGrace = defaultResultsGrace
+	}
+	if cfg.JobTTL <= 0 {
+		cfg.JobTTL = defaultJobTTL
+	}
+	roots, err := newScanRoots(cfg.AllowedRoots)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{cfg: cfg, ctx: ctx, roots: roots, jobs: make(map[sources.JobID]*job)}
+	go s.evictJobs()
+	return s, nil
+}
+
+// evictJobs periodically removes jobs that finished more than the job TTL
+// ago, until the server's context is cancelled.
+func (s *Server) evictJobs() {
+	ticker := time.NewTicker(min(s.cfg.JobTTL, time.Minute))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.evict(now)
+		}
+	}
+}
+
+func (s *Server) evict(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, j := range s.jobs {
+		if j.expired(now, s.cfg.JobTTL) {
+			delete(s.jobs, id)
+			s.ctx.Logger().V(2).Info("evicted finished scan job", "job_id", id)
+		}
+	}
+}
+
+// Handler returns the HTTP API:
+//
+//	POST   /v1/jobs              submit a job
+//	GET    /v1/jobs              list jobs
+//	GET    /v1/jobs/{id}         get job progress
+//	GET    /v1/jobs/{id}/results stream results as JSON lines
+//	DELETE /v1/jobs/{id}         cancel a job
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/jobs", s.handleSubmit)
+	mux.HandleFunc("GET /v1/jobs", s.handleList)
+	mux.HandleFunc("GET /v1/jobs/{id}", s.handleGet)
+	mux.HandleFunc("GET /v1/jobs/{id}/results", s.handleResults)
+	mux.HandleFunc("DELETE /v1/jobs/{id}", s.handleCancel)
+	if s.cfg.Insecure && s.cfg.Token == "" {
+		return mux
+	}
+	return requireToken(s.cfg.Token, mux)
+}
+
+// requireToken rejects requests that do not carry the bearer token.
+func requireToken(token string, next http.Handler) http.Handler {
+	want := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Print routes a result to the job that produced it.
+func (s *Server) Print(_ context.Context, r *detectors.ResultWithMetadata) error {
+	s.mu.RLock()
+	j, ok := s.jobs[r.JobID]
+	s.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("result for unknown job %d", r.JobID)
+	}
+
+	rec, err := output.NewResultRecord(r, output.RecordOptions{IncludeSecrets: s.cfg.IncludeSecrets})
+	if err != nil {
+		return fmt.Errorf("could not marshal result: %w", err)
+	}
+	j.addResult(rec)
+	return nil
+}
+
+// SubmitRequest is the body of POST /v1/jobs.
+type SubmitRequest struct {
+	// SourceType is a sourcespb.SourceType name such as
+	// "SOURCE_TYPE_FILESYSTEM".
+	SourceType string `json:"source_type"`
+	// Name identifies the job in progress reports. It defaults to the source
+	// type.
+	Name   string `json:"name"`
+	Verify bool   `json:"verify"`
+	// Connection is the source's connection message as a protobuf Any in
+	// its JSON form, e.g.
+	// {"@type": "type.googleapis.com/sources.Filesystem", "paths": ["/src"]}.
+	Connection json.RawMessage `json:"connection"`
+}
+
+// Submit initializes a source and enqueues it on the source manager.
+func (s *Server) Submit(req SubmitRequest) (JobStatus, error) {
+	typ, ok := sourcespb.SourceType_value[req.SourceType]
+	if !ok {
+		return JobStatus{}, fmt.Errorf("%w: unknown source type %q", errInvalidRequest, req.SourceType)
+	}
+	sourceType := sourcespb.SourceType(typ)
+	newSource, ok := s.cfg.Sources[sourceType]
+	if !ok {
+		return JobStatus{}, fmt.Errorf("%w: source type %s is not supported", errInvalidRequest, req.SourceType)
+	}
+
+	var conn anypb.Any
+	if len(req.Connection) > 0 && string(req.Connection) != "null" {
+		if err := protojson.Unmarshal(req.Connection, &conn); err != nil {
+			return JobStatus{}, fmt.Errorf("%w: invalid connection: %v", errInvalidRequest, err)
+		}
+	}
+	paths, err := localPaths(&conn)
+	if err != nil {
+		return JobStatus{}, fmt.Errorf("%w: invalid connection: %v", errInvalidRequest, err)
+	}
+	if err := s.roots.check(paths); err != nil {
+		return JobStatus{}, err
+	}
+	name := req.Name
+	if name == "" {
+		name = req.SourceType
+	}
+
+	sourceID, jobID, err := s.cfg.Manager.GetIDs(s.ctx, name, sourceType)
+	if err != nil {
+		return JobStatus{}, fmt.Errorf("unable to allocate job: %w", err)
+	}
+	src := newSource()
+	if err := src.Init(s.ctx, name, jobID, sourceID, req.Verify, &conn, s.cfg.Concurrency); err != nil {
+		return JobStatus{}, fmt.Errorf("%w: unable to initialize source: %v", errInvalidRequest, err)
+	}
+
+	// Register the job before enqueueing so that no result can arrive for a
+	// job the server does not know about yet.
+	j := newJob(jobID, name, sourceType)
+	s.mu.Lock()
+	s.jobs[jobID] = j
+	s.mu.Unlock()
+
+	ref, err := s.cfg.Manager.Enqueue(s.ctx, name, src)
+	if err != nil {
+		s.mu.Lock()
+		delete(s.jobs, jobID)
+		s.mu.Unlock()
+		return JobStatus{}, fmt.Errorf("unable to enqueue job: %w", err)
+	}
+	j.setRef(ref)
+	s.ctx.Logger().Info("scan job submitted", "job_id", jobID, "name", name, "source_type", req.SourceType)
+	return j.status(), nil
+}
+
+var (
+	errInvalidRequest = errors.New("invalid request")
+	errNotFound       = errors.New("job not found")
+)
+
+func (s *Server) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	var req SubmitRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSubmitBodySize)).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": err.Error()})
+			return
+		}
+		writeError(w, fmt.Errorf("%w: %v", errInvalidRequest, err))
+		return
+	}
+	status, err := s.Submit(req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, status)
+}
+
+func (s *Server) handleList(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, s.jobStatuses())
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	j, err := s.job(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, j.status())
+}
+
+func (s *Server) handleCancel(w http.ResponseWriter, r *http.Request) {
+	j, err := s.job(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	j.cancel()
+	writeJSON(w, http.StatusOK, j.status())
+}
+
+// handleResults streams the results of a job as JSON lines. All results found
+// so far are sent first; with ?follow=true the stream stays open until the job
+// is done and no more results arrive.
+func (s *Server) handleResults(w http.ResponseWriter, r *http.Request) {
+	j, err := s.job(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	follow, _ := strconv.ParseBool(r.URL.Query().Get("follow"))
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+
+	_ = s.streamResults(r.Context(), j, follow, func(results []output.ResultRecord) error {
+		for _, rec := range results {
+			if err := enc.Encode(rec); err != nil {
+				return err
+			}
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+}
+
+// streamResults passes the results of j to send as they arrive. Without
+// follow, only the results found so far are sent. With follow, it returns
+// once the job is done and no more results arrived within the grace period.
+func (s *Server) streamResults(ctx stdcontext.Context, j *job, follow bool, send func([]output.ResultRecord) error) error {
+	next := 0
+	for {
+		results, updated, done := j.resultsSince(next)
+		if err := send(results); err != nil {
+			return err
+		}
+		next += len(results)
+		if !follow {
+			return nil
+		}
+
+		// Wait for the next result. Once the source is done, the grace period
+		// restarts with every late result.
+		var (
+			grace  <-chan time.Time
+			finish <-chan struct{}
+		)
+		if done {
+			grace = time.After(s.cfg.ResultsGrace)
+		} else {
+			finish = j.done()
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		case <-grace:
+			return nil
+		case <-updated:
+		case <-finish:
+		}
+	}
+}
+
+func (s *Server) job(r *http.Request) (*job, error) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid job id", errInvalidRequest)
+	}
+	return s.jobByID(sources.JobID(id))
+}
+
+func (s *Server) jobByID(id sources.JobID) (*job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	j, ok := s.jobs[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	return j, nil
+}
+
+// jobStatuses returns the status of every job ordered by ID.
+func (s *Server) jobStatuses() []JobStatus {
+	s.mu.RLock()
+	statuses := make([]JobStatus, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		statuses = append(statuses, j.status())
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].JobID < statuses[j].JobID })
+	return statuses
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, errInvalidRequest):
+		code = http.StatusBadRequest
+	case errors.Is(err, errNotFound):
+		code = http.StatusNotFound
+	case errors.Is(err, errPathNotAllowed):
+		code = http.StatusForbidden
+	}
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+type fakeSource struct {
+	sources.Progress
+	name     string
+	jobID    sources.JobID
+	sourceID sources.SourceID
+}
+
+func (s *fakeSource) Type() sourcespb.SourceType {
+	return sourcespb.SourceType_SOURCE_TYPE_FILESYSTEM
+}
+func (s *fakeSource) SourceID() sources.SourceID { return s.sourceID }
+func (s *fakeSource) JobID() sources.JobID       { return s.jobID }
+
+func (s *fakeSource) Init(_ context.Context, name string, jobID sources.JobID, sourceID sources.SourceID, _ bool, _ *anypb.Any, _ int) error {
+	s.name, s.jobID, s.sourceID = name, jobID, sourceID
+	return nil
+}
+
+func (s *fakeSource) Chunks(context.Context, chan *sources.Chunk, ...sources.ChunkingTarget) error {
+	return nil
+}
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+	s, err := New(ctx, Config{
+		Manager: sources.NewManager(),
+		Sources: map[sourcespb.SourceType]SourceFactory{
+			sourcespb.SourceType_SOURCE_TYPE_FILESYSTEM: func() sources.Source { return &fakeSource{} },
+		},
+		Token: "token",
+	})
+	require.NoError(t, err)
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	do := func(method, path, body string) *http.Response {
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer token")
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	resp, err := ts.Client().Get(ts.URL + "/v1/jobs")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp = do(http.MethodPost, "/v1/jobs", `{"source_type": "SOURCE_TYPE_GIT"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = do(http.MethodPost, "/v1/jobs", `{"name": "`+strings.Repeat("a", maxSubmitBodySize)+`"}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+
+	resp = do(http.MethodPost, "/v1/jobs", `{"source_type": "SOURCE_TYPE_FILESYSTEM", "name": "fs"}`)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	var submitted JobStatus
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&submitted))
+	assert.Equal(t, "fs", submitted.Name)
+
+	result := &detectors.ResultWithMetadata{
+		JobID: sources.JobID(submitted.JobID),
+		SourceMetadata: &source_metadatapb.MetaData{
+			Data: &source_metadatapb.MetaData_Filesystem{
+				Filesystem: &source_metadatapb.Filesystem{File: "a.txt", Line: 1},
+			},
+		},
+		Result: detectors.Result{Raw: []byte("secret")},
+	}
+	require.NoError(t, s.Print(ctx, result))
+
+	resp = do(http.MethodGet, fmt.Sprintf("/v1/jobs/%d/results", submitted.JobID), "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var lines []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	require.Len(t, lines, 1)
+	assert.NotContains(t, lines[0], "secret", "results are redacted by default")
+
+	resp = do(http.MethodGet, fmt.Sprintf("/v1/jobs/%d", submitted.JobID), "")
+	var status JobStatus
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&status))
+	assert.Equal(t, 1, status.Results)
+
+	resp = do(http.MethodGet, "/v1/jobs/999", "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// Unfinished jobs are never evicted.
+	s.evict(time.Now().Add(2 * defaultJobTTL))
+	resp = do(http.MethodGet, fmt.Sprintf("/v1/jobs/%d", submitted.JobID), "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestServerRequiresToken(t *testing.T) {
+	ctx := context.Background()
+	_, err := New(ctx, Config{Manager: sources.NewManager()})
+	assert.Error(t, err)
+
+	s, err := New(ctx, Config{Manager: sources.NewManager(), Insecure: true})
+	require.NoError(t, err)
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+	resp, err := ts.Client().Get(ts.URL + "/v1/jobs")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestScanRoots(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, "repo"), 0o755))
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "escape")))
+
+	roots, err := newScanRoots([]string{root})
+	require.NoError(t, err)
+
+	assert.NoError(t, roots.check([]string{root, filepath.Join(root, "repo")}))
+	assert.ErrorIs(t, roots.check([]string{outside}), errPathNotAllowed)
+	assert.ErrorIs(t, roots.check([]string{filepath.Join(root, "..")}), errPathNotAllowed)
+	assert.ErrorIs(t, roots.check([]string{filepath.Join(root, "escape")}), errPathNotAllowed)
+	assert.ErrorIs(t, roots.check([]string{filepath.Join(root, "missing")}), errPathNotAllowed)
+
+	// Without roots, no local path is allowed.
+	assert.ErrorIs(t, scanRoots(nil).check([]string{root}), errPathNotAllowed)
+	assert.NoError(t, scanRoots(nil).check(nil))
+}
+
+func TestLocalRepoPath(t *testing.T) {
+	tests := []struct {
+		uri   string
+		path  string
+		local bool
+	}{
+		{uri: "https://github.com/org/repo.git"},
+		{uri: "ssh://git@github.com/org/repo.git"},
+		{uri: "git@github.com:org/repo.git"},
+		{uri: "file:///srv/repo", path: "/srv/repo", local: true},
+		{uri: "/srv/repo", path: "/srv/repo", local: true},
+		{uri: "../repo", path: "../repo", local: true},
+	}
+	for _, tt := range tests {
+		path, local := localRepoPath(tt.uri)
+		assert.Equal(t, tt.local, local, tt.uri)
+		assert.Equal(t, tt.path, path, tt.uri)
+	}
+}
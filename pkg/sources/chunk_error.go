@@ -0,0 +1,18 @@
+package sources
+
+import "fmt"
+
+// ChunkError is an error encountered while chunking a specific unit. The
+// source manager must wrap the errors of ChunkUnit and ChunkReporter.ChunkErr
+// in it before passing them to JobProgressHook.ReportError. Hooks rely on it
+// to tell which unit failed and cannot attribute a plain error to any unit.
+type ChunkError struct {
+	Unit SourceUnit
+	Err  error
+}
+
+func (e ChunkError) Error() string {
+	return fmt.Sprintf("error chunking unit %q: %s", e.Unit.Display(), e.Err.Error())
+}
+
+func (e ChunkError) Unwrap() error { return e.Err }
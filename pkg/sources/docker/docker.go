@@ -22,6 +22,7 @@ import (
 
 	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/membudget"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
@@ -36,13 +37,19 @@ type Source struct {
 	verify      bool
 	concurrency int
 	conn        sourcespb.Docker
+	memBudget   *membudget.Budget
 	sources.Progress
 	sources.CommonSourceUnitUnmarshaller
 }
 
+// WithMemoryBudget makes the source reserve the size of every chunk in b
+// before sending it, blocking while the budget is used up.
+func (s *Source) WithMemoryBudget(b *membudget.Budget) { s.memBudget = b }
+
 // Ensure the Source satisfies the interfaces at compile time.
 var _ sources.Source = (*Source)(nil)
 var _ sources.SourceUnitUnmarshaller = (*Source)(nil)
+var _ membudget.Budgeted = (*Source)(nil)
 
 // Type returns the type of source.
 // It is used for matching source types in configuration and job input.
@@ -280,7 +287,7 @@ func (s *Source) processHistoryEntry(ctx context.Context, historyInfo historyEnt
 
 	ctx.Logger().V(2).Info("scanning image history entry", "index", historyInfo.index, "layer", historyInfo.layerDigest)
 
-	return common.CancellableWrite(ctx, chunksChan, chunk)
+	return s.memBudget.ChanReporter(chunksChan).ChunkOk(ctx, *chunk)
 }
 
 // processLayer processes an individual layer of an image.
@@ -351,6 +358,7 @@ func (s *Source) processChunk(ctx context.Context, info chunkProcessingInfo, chu
 
 	chunkReader := sources.NewChunkReader()
 	chunkResChan := chunkReader(ctx, info.reader)
+	reporter := s.memBudget.ChanReporter(chunksChan)
 
 	for data := range chunkResChan {
 		if err := data.Error(); err != nil {
@@ -376,7 +384,7 @@ func (s *Source) processChunk(ctx context.Context, info chunkProcessingInfo, chu
 		}
 		chunk.Data = data.Bytes()
 
-		if err := common.CancellableWrite(ctx, chunksChan, chunk); err != nil {
+		if err := reporter.ChunkOk(ctx, *chunk); err != nil {
 			return err
 		}
 	}
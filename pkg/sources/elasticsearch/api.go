@@ -57,7 +57,11 @@ type Indices struct {
 	documentCount           int
 	processedDocumentsCount int
 	filterParams            *FilterParams
-	lock                    sync.RWMutex
+	// lastScanned holds the latest document timestamp of each index as
+	// recorded by a previous scan. Indices seen for the first time continue
+	// from there.
+	lastScanned map[string]time.Time
+	lock        sync.RWMutex
 }
 
 type elasticSearchRequest interface {
@@ -129,6 +133,13 @@ func (i *Index) DocumentAlreadySeen(document *Document) bool {
 	return false
 }
 
+// LatestTimestamp returns the timestamp of the latest document seen.
+func (i *Index) LatestTimestamp() time.Time {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	return i.latestTimestamp
+}
+
 func (i *Index) UpdateLatestTimestampLastRun() {
 	i.lock.Lock()
 	i.latestTimestampLastRun = i.latestTimestamp
@@ -438,6 +449,10 @@ func (indices *Indices) Update(
 		} else {
 			index = NewIndex()
 			index.name = name
+			if ts, ok := indices.lastScanned[name]; ok {
+				index.latestTimestamp = ts
+				index.latestTimestampLastRun = ts
+			}
 			newIndicesByName[name] = index
 		}
 	}
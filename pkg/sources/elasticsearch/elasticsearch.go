@@ -14,6 +14,7 @@ import (
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/statestore"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/anypb"
@@ -33,9 +34,15 @@ type Source struct {
 	ctx            context.Context
 	client         *es.TypedClient
 	log            logr.Logger
+	stateStore     statestore.Store
 	sources.Progress
 }
 
+// WithStateStore makes the source only scan the documents of each index that
+// are at least as new as the latest one seen by the last scan recorded in
+// store. Documents sharing that latest timestamp are scanned again.
+func (s *Source) WithStateStore(store statestore.Store) { s.stateStore = store }
+
 // Init returns an initialized Elasticsearch source
 func (s *Source) Init(
 	aCtx context.Context,
@@ -130,7 +137,7 @@ func (s *Source) Chunks(
 	chunksChan chan *sources.Chunk,
 	targets ...sources.ChunkingTarget,
 ) error {
-	indices := Indices{filterParams: &s.filterParams}
+	indices := Indices{filterParams: &s.filterParams, lastScanned: s.loadLastScanned(ctx)}
 
 	for {
 		workerPool := new(errgroup.Group)
@@ -205,6 +212,8 @@ func (s *Source) Chunks(
 								Verify: s.verify,
 							}
 
+							// The whole message is sent as one chunk, which lets the
+							// output report the line and offset of a finding within it.
 							chunk.Data = []byte(document.message)
 
 							return common.CancellableWrite(ctx, chunksChan, &chunk)
@@ -267,6 +276,8 @@ func (s *Source) Chunks(
 		err = workerPool.Wait()
 		if err != nil {
 			s.log.V(2).Info(fmt.Sprintf("Error waiting on worker pool: %s\n", err))
+		} else {
+			s.recordScanned(ctx, &indices)
 		}
 
 		if !s.bestEffortScan {
@@ -276,3 +287,39 @@ func (s *Source) Chunks(
 
 	return nil
 }
+
+// loadLastScanned reads the latest document timestamp of each index recorded
+// by a previous scan.
+func (s *Source) loadLastScanned(ctx context.Context) map[string]time.Time {
+	if s.stateStore == nil {
+		return nil
+	}
+	markers, err := s.stateStore.List(ctx, s.name)
+	if err != nil {
+		s.log.V(2).Info("unable to read scan state", "error", err)
+		return nil
+	}
+	lastScanned := make(map[string]time.Time, len(markers))
+	for index, marker := range markers {
+		if ts, err := time.Parse(time.RFC3339Nano, marker); err == nil {
+			lastScanned[index] = ts
+		}
+	}
+	return lastScanned
+}
+
+// recordScanned records the latest document timestamp of every index.
+func (s *Source) recordScanned(ctx context.Context, indices *Indices) {
+	if s.stateStore == nil {
+		return
+	}
+	for _, index := range indices.indices {
+		ts := index.LatestTimestamp()
+		if ts.IsZero() {
+			continue
+		}
+		if err := s.stateStore.Set(ctx, s.name, index.name, ts.Format(time.RFC3339Nano)); err != nil {
+			s.log.V(2).Info("unable to record scan state", "index", index.name, "error", err)
+		}
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/handlers"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/membudget"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
@@ -33,14 +34,20 @@ type Source struct {
 	paths       []string
 	log         logr.Logger
 	filter      *common.Filter
+	memBudget   *membudget.Budget
 	sources.Progress
 	sources.CommonSourceUnitUnmarshaller
 }
 
+// WithMemoryBudget makes the source reserve the size of every chunk in b
+// before sending it, blocking while the budget is used up.
+func (s *Source) WithMemoryBudget(b *membudget.Budget) { s.memBudget = b }
+
 // Ensure the Source satisfies the interfaces at compile time
 var _ sources.Source = (*Source)(nil)
 var _ sources.SourceUnitUnmarshaller = (*Source)(nil)
 var _ sources.SourceUnitEnumChunker = (*Source)(nil)
+var _ membudget.Budgeted = (*Source)(nil)
 
 // Type returns the type of source.
 // It is used for matching source types in configuration and job input.
@@ -185,7 +192,7 @@ func (s *Source) scanFile(ctx context.Context, path string, chunksChan chan *sou
 		Verify: s.verify,
 	}
 
-	return handlers.HandleFile(fileCtx, inputFile, chunkSkel, sources.ChanReporter{Ch: chunksChan})
+	return handlers.HandleFile(fileCtx, inputFile, chunkSkel, s.memBudget.ChanReporter(chunksChan))
 }
 
 // Enumerate implements SourceUnitEnumerator interface. This implementation simply
@@ -22,10 +22,12 @@ import (
 	"github.com/trufflesecurity/trufflehog/v3/pkg/cache/simple"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/handlers"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/membudget"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/credentialspb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/statestore"
 )
 
 const (
@@ -71,12 +73,22 @@ type Source struct {
 	stats      *attributes
 	log        logr.Logger
 	chunksCh   chan *sources.Chunk
+	stateStore statestore.Store
+	memBudget  *membudget.Budget
 
 	mu               sync.Mutex
 	sources.Progress // progress is not thread safe
 	sources.CommonSourceUnitUnmarshaller
 }
 
+// WithStateStore makes the source skip objects whose MD5 did not change since
+// the last scan recorded in store.
+func (s *Source) WithStateStore(store statestore.Store) { s.stateStore = store }
+
+// WithMemoryBudget makes the source reserve the size of every chunk in b
+// before sending it, blocking while the budget is used up.
+func (s *Source) WithMemoryBudget(b *membudget.Budget) { s.memBudget = b }
+
 // persistableCache is a wrapper around cache.Cache that allows
 // for the persistence of the cache contents in the Progress of the source
 // at given increments.
@@ -275,6 +287,10 @@ func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ .
 			ctx.Logger().V(5).Info("skipping object, object already processed", "name", o.name)
 			continue
 		}
+		if s.unchangedSinceLastScan(ctx, o) {
+			ctx.Logger().V(5).Info("skipping object, object unchanged since last scan", "name", o.name)
+			continue
+		}
 
 		wg.Add(1)
 		go func(obj object) {
@@ -285,6 +301,7 @@ func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ .
 				return
 			}
 			s.setProgress(ctx, o.md5, o.name, persistableCache)
+			s.recordScanned(ctx, o)
 		}(o)
 	}
 	wg.Wait()
@@ -313,6 +330,32 @@ func (s *Source) setupCache(ctx context.Context) *persistableCache {
 	return persistCache
 }
 
+// stateKey identifies an object in the state store.
+func stateKey(o object) string { return o.bucket + "/" + o.name }
+
+// unchangedSinceLastScan reports whether the state store recorded the
+// object's current MD5.
+func (s *Source) unchangedSinceLastScan(ctx context.Context, o object) bool {
+	if s.stateStore == nil || o.md5 == "" {
+		return false
+	}
+	md5, ok, err := s.stateStore.Get(ctx, s.name, stateKey(o))
+	if err != nil {
+		ctx.Logger().V(2).Info("unable to read scan state", "name", o.name, "error", err)
+		return false
+	}
+	return ok && md5 == o.md5
+}
+
+func (s *Source) recordScanned(ctx context.Context, o object) {
+	if s.stateStore == nil || o.md5 == "" {
+		return
+	}
+	if err := s.stateStore.Set(ctx, s.name, stateKey(o), o.md5); err != nil {
+		ctx.Logger().V(2).Info("unable to record scan state", "name", o.name, "error", err)
+	}
+}
+
 func (s *Source) setProgress(ctx context.Context, md5, objName string, cache cache.Cache[string]) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -354,5 +397,5 @@ func (s *Source) processObject(ctx context.Context, o object) error {
 		},
 	}
 
-	return handlers.HandleFile(ctx, io.NopCloser(o), chunkSkel, sources.ChanReporter{Ch: s.chunksCh})
+	return handlers.HandleFile(ctx, io.NopCloser(o), chunkSkel, s.memBudget.ChanReporter(s.chunksCh))
 }
@@ -12,6 +12,8 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"slices"
+	"sort"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -31,10 +33,12 @@ import (
 	"github.com/trufflesecurity/trufflehog/v3/pkg/feature"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/gitparse"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/handlers"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/membudget"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/statestore"
 )
 
 const SourceType = sourcespb.SourceType_SOURCE_TYPE_GIT
@@ -48,6 +52,8 @@ type Source struct {
 	useCustomContentWriter bool
 	git                    *Git
 	scanOptions            *ScanOptions
+	stateStore             statestore.Store
+	memBudget              *membudget.Budget
 
 	sources.Progress
 	conn *sourcespb.Git
@@ -56,6 +62,14 @@ type Source struct {
 // WithCustomContentWriter sets the useCustomContentWriter flag on the source.
 func (s *Source) WithCustomContentWriter() { s.useCustomContentWriter = true }
 
+// WithStateStore makes the source only scan the commits added since the last
+// scan recorded in store.
+func (s *Source) WithStateStore(store statestore.Store) { s.stateStore = store }
+
+// WithMemoryBudget makes the source reserve the size of every chunk in b
+// before sending it, blocking while the budget is used up.
+func (s *Source) WithMemoryBudget(b *membudget.Budget) { s.memBudget = b }
+
 type Git struct {
 	sourceType         sourcespb.SourceType
 	sourceName         string
@@ -67,6 +81,7 @@ type Git struct {
 	concurrency        *semaphore.Weighted
 	skipBinaries       bool
 	skipArchives       bool
+	stateStore         statestore.Store
 
 	parser *gitparse.Parser
 }
@@ -92,6 +107,10 @@ type Config struct {
 	// When set to true, the parser will use a custom contentWriter provided through the WithContentWriter option.
 	// When false, the parser will use the default buffer (in-memory) contentWriter.
 	UseCustomContentWriter bool
+
+	// StateStore, if set, records the head commit of each scanned repository,
+	// so that the next scan only covers the commits added since.
+	StateStore statestore.Store
 }
 
 // NewGit creates a new Git instance with the provided configuration. The Git instance is used to interact with
@@ -114,6 +133,7 @@ func NewGit(config *Config) *Git {
 		concurrency:        semaphore.NewWeighted(int64(config.Concurrency)),
 		skipBinaries:       config.SkipBinaries,
 		skipArchives:       config.SkipArchives,
+		stateStore:         config.StateStore,
 		parser:             parser,
 	}
 }
@@ -225,6 +245,7 @@ func (s *Source) Init(aCtx context.Context, name string, jobId sources.JobID, so
 			}
 		},
 		UseCustomContentWriter: s.useCustomContentWriter,
+		StateStore:             s.stateStore,
 	}
 	s.git = NewGit(cfg)
 	return nil
@@ -232,7 +253,7 @@ func (s *Source) Init(aCtx context.Context, name string, jobId sources.JobID, so
 
 // Chunks emits chunks of bytes over a channel.
 func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ ...sources.ChunkingTarget) error {
-	reporter := sources.ChanReporter{Ch: chunksChan}
+	reporter := s.memBudget.ChanReporter(chunksChan)
 	if err := s.scanRepos(ctx, reporter); err != nil {
 		return err
 	}
@@ -568,7 +589,15 @@ func (s *Git) ScanCommits(ctx context.Context, repo *git.Repository, path string
 		logValues = append(logValues, "max_depth", scanOptions.MaxDepth)
 	}
 
-	diffChan, err := s.parser.RepoPath(repoCtx, path, scanOptions.HeadHash, scanOptions.BaseHash == "", scanOptions.ExcludeGlobs, scanOptions.Bare)
+	var (
+		diffChan chan *gitparse.Diff
+		err      error
+	)
+	if scanOptions.BaseHash != "" || len(scanOptions.ExcludeCommits) > 0 {
+		diffChan, err = s.logRange(repoCtx, path, scanOptions)
+	} else {
+		diffChan, err = s.parser.RepoPath(repoCtx, path, scanOptions.HeadHash, true, scanOptions.ExcludeGlobs, scanOptions.Bare)
+	}
 	if err != nil {
 		return err
 	}
@@ -592,11 +621,6 @@ func (s *Git) ScanCommits(ctx context.Context, repo *git.Repository, path string
 
 		commit := diff.Commit
 		fullHash := commit.Hash
-		if scanOptions.BaseHash != "" && scanOptions.BaseHash == fullHash {
-			logger.V(1).Info("reached base commit", "commit", fullHash)
-			break
-		}
-
 		email := commit.Author
 		when := commit.Date.UTC().Format("2006-01-02 15:04:05 -0700")
 
@@ -710,6 +734,73 @@ func (s *Git) ScanCommits(ctx context.Context, repo *git.Repository, path string
 	return nil
 }
 
+// logRange runs git log over the commits that are reachable from
+// scanOptions.HeadHash, or from every ref if it is empty, but not from
+// BaseHash or any of ExcludeCommits, and parses its output. The exclusion
+// follows the commit graph, so unlike a walk that stops once it reaches
+// BaseHash, commits dated before the base are neither missed nor scanned
+// twice, and the range is walked in a single pass however many commits it
+// holds.
+//
+// The arguments match those of gitparse.Parser.RepoPath apart from the
+// revisions. The environment is inherited, so that the hook of a receiving
+// repository sees the pushed objects in its quarantine directory.
+func (s *Git) logRange(ctx context.Context, path string, scanOptions *ScanOptions) (chan *gitparse.Diff, error) {
+	args := []string{
+		"-C", path,
+		"log",
+		"--patch",
+		"--full-history",
+		"--date=format:%a %b %d %H:%M:%S %Y %z",
+		"--pretty=fuller",
+		"--notes",
+	}
+	if scanOptions.BaseHash == "" {
+		args = append(args, "--diff-filter=AM")
+	}
+	if scanOptions.HeadHash != "" {
+		args = append(args, scanOptions.HeadHash)
+	} else {
+		args = append(args, "--all")
+	}
+	if scanOptions.BaseHash != "" {
+		args = append(args, "^"+scanOptions.BaseHash)
+	}
+	for _, commit := range scanOptions.ExcludeCommits {
+		args = append(args, "^"+commit)
+	}
+	if len(scanOptions.ExcludeGlobs) > 0 {
+		args = append(args, "--", ".")
+		for _, glob := range scanOptions.ExcludeGlobs {
+			args = append(args, ":(exclude)"+glob)
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Env = os.Environ()
+	if abs, err := filepath.Abs(path); err == nil {
+		cmd.Env = append(cmd.Env, "GIT_DIR="+getGitDir(abs, scanOptions))
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read git log output: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("unable to run git log: %w", err)
+	}
+
+	diffChan := make(chan *gitparse.Diff, 64)
+	go func() {
+		s.parser.FromReader(ctx, stdout, diffChan, false)
+		if err := cmd.Wait(); err != nil {
+			ctx.Logger().Error(err, "git log failed", "path", path, "stderr", strings.TrimSpace(stderr.String()))
+		}
+	}()
+	return diffChan, nil
+}
+
 func (s *Git) gitChunk(ctx context.Context, diff *gitparse.Diff, fileName, email, hash, when, urlMetadata string, reporter sources.ChunkReporter) {
 	reader, err := diff.ReadCloser()
 	if err != nil {
@@ -921,8 +1012,26 @@ func (s *Git) ScanRepo(ctx context.Context, repo *git.Repository, repoPath strin
 	}
 	start := time.Now().Unix()
 
-	if err := s.ScanCommits(ctx, repo, repoPath, scanOptions, reporter); err != nil {
-		return err
+	inc := s.sinceLastScan(ctx, repo, repoPath, scanOptions)
+	switch {
+	case inc == nil || inc.full:
+		if err := s.ScanCommits(ctx, repo, repoPath, scanOptions, reporter); err != nil {
+			return err
+		}
+	case len(inc.commits) == 0:
+		ctx.Logger().V(1).Info("no commits since the last scan", "path", repoPath)
+	default:
+		ctx.Logger().V(1).Info("scanning commits since the last scan", "path", repoPath, "commits", len(inc.commits))
+		opts := *scanOptions
+		opts.ExcludeCommits = append(slices.Clip(opts.ExcludeCommits), inc.scanned...)
+		if err := s.ScanCommits(ctx, repo, repoPath, &opts, reporter); err != nil {
+			return err
+		}
+	}
+	if inc != nil {
+		if err := s.stateStore.Set(ctx, s.sourceName, inc.key, strings.Join(inc.tips, ",")); err != nil {
+			ctx.Logger().Error(err, "unable to record scanned ref tips", "path", repoPath)
+		}
 	}
 	if !scanOptions.Bare {
 		if err := s.ScanStaged(ctx, repo, repoPath, scanOptions, reporter); err != nil {
@@ -953,6 +1062,129 @@ func (s *Git) ScanRepo(ctx context.Context, repo *git.Repository, repoPath strin
 	return nil
 }
 
+// incrementalScan describes a scan that continues from the ref tips
+// recorded by the previous scan of a repository.
+type incrementalScan struct {
+	// key identifies the repository in the state store.
+	key string
+	// tips are the commits that the repository's refs point to now. They
+	// are recorded once the scan is done.
+	tips []string
+	// full is set if the whole repository must be scanned.
+	full bool
+	// scanned are the recorded tips that still exist. The scan excludes
+	// every commit reachable from them.
+	scanned []string
+	// commits are the commits reachable from tips but from none of
+	// scanned, oldest first.
+	commits []string
+}
+
+// sinceLastScan returns how to scan only the commits added to repo since the
+// ref tips recorded in the state store. It returns nil if there is no state
+// store, or if the scan options already select a commit range, which takes
+// precedence. If nothing was recorded yet, the whole repository is scanned
+// and its tips recorded.
+//
+// Every ref is recorded rather than HEAD alone because a scan walks all refs:
+// new commits on other branches, and branch commits dated before HEAD, must
+// not be skipped.
+func (s *Git) sinceLastScan(ctx context.Context, repo *git.Repository, repoPath string, scanOptions *ScanOptions) *incrementalScan {
+	if s.stateStore == nil || scanOptions.BaseHash != "" || scanOptions.HeadHash != "" {
+		return nil
+	}
+	tips, err := refTips(repo)
+	if err != nil {
+		ctx.Logger().V(2).Info("unable to list refs, scanning without state", "path", repoPath, "error", err)
+		return nil
+	}
+
+	inc := &incrementalScan{key: getSafeRemoteURL(repo, "origin"), tips: tips, full: true}
+	if inc.key == "" {
+		if abs, err := filepath.Abs(repoPath); err == nil {
+			repoPath = abs
+		}
+		inc.key = repoPath
+	}
+
+	last, ok, err := s.stateStore.Get(ctx, s.sourceName, inc.key)
+	if err != nil {
+		ctx.Logger().Error(err, "unable to read scan state, scanning the whole repository", "path", repoPath)
+		return inc
+	}
+	if !ok {
+		return inc
+	}
+
+	// A rewritten history can drop recorded commits.
+	var scanned []string
+	for _, tip := range strings.Split(last, ",") {
+		if repo.Storer.HasEncodedObject(plumbing.NewHash(tip)) == nil {
+			scanned = append(scanned, tip)
+		}
+	}
+	if len(scanned) == 0 {
+		ctx.Logger().V(1).Info("last scanned commits not found, scanning the whole repository", "path", repoPath)
+		return inc
+	}
+
+	commits, err := commitsSince(ctx, repoPath, scanned)
+	if err != nil {
+		ctx.Logger().Error(err, "unable to list new commits, scanning the whole repository", "path", repoPath)
+		return inc
+	}
+	inc.full = false
+	inc.scanned = scanned
+	inc.commits = commits
+	return inc
+}
+
+// refTips returns the sorted, distinct hashes that HEAD and every ref point to.
+func refTips(repo *git.Repository) ([]string, error) {
+	refs, err := repo.References()
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]struct{})
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() == plumbing.HashReference {
+			seen[ref.Hash().String()] = struct{}{}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if head, err := repo.Head(); err == nil {
+		seen[head.Hash().String()] = struct{}{}
+	}
+
+	tips := make([]string, 0, len(seen))
+	for tip := range seen {
+		tips = append(tips, tip)
+	}
+	sort.Strings(tips)
+	return tips, nil
+}
+
+// commitsSince returns the commits reachable from any ref but from none of
+// scanned, oldest first.
+func commitsSince(ctx context.Context, repoPath string, scanned []string) ([]string, error) {
+	var stdin strings.Builder
+	for _, tip := range scanned {
+		stdin.WriteString("^" + tip + "\n")
+	}
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "rev-list", "--reverse", "--topo-order", "--all", "--stdin")
+	cmd.Stdin = strings.NewReader(stdin.String())
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("unable to list commits: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.Fields(string(out)), nil
+}
+
 // normalizeConfig updates scanOptions with the resolved base and head commit hashes.
 // It's designed to handle scenarios where BaseHash and HeadHash in scanOptions might be branch names or
 // other non-hash references. This ensures that both the base and head commits are resolved to actual commit hashes.
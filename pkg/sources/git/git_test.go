@@ -3,9 +3,12 @@ package git
 import (
 	"bytes"
 	"fmt"
+	"os"
+	"os/exec"
 	"strings"
 	"testing"
 
+	"github.com/go-git/go-git/v5"
 	"github.com/kylelemons/godebug/pretty"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/protobuf/types/known/anypb"
@@ -597,3 +600,58 @@ func TestChunkUnit(t *testing.T) {
 	assert.Equal(t, 22, len(reporter.Chunks))
 	assert.Equal(t, 1, len(reporter.ChunkErrs))
 }
+
+func TestCommitsSince(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	run := func(date string, args ...string) string {
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+		out, err := cmd.CombinedOutput()
+		assert.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	commit := func(msg, date string) string {
+		run(date, "commit", "-q", "--allow-empty", "-m", msg)
+		return run(date, "rev-parse", "HEAD")
+	}
+
+	run("", "init", "-q", "-b", "main")
+	commit("base", "2020-01-01T00:00:00Z")
+	run("", "checkout", "-q", "-b", "feature")
+	run("", "checkout", "-q", "main")
+	commit("main", "2021-01-01T00:00:00Z")
+
+	repo, err := git.PlainOpen(dir)
+	assert.NoError(t, err)
+	scanned, err := refTips(repo)
+	assert.NoError(t, err)
+	assert.Len(t, scanned, 2)
+
+	commits, err := commitsSince(ctx, dir, scanned)
+	assert.NoError(t, err)
+	assert.Empty(t, commits)
+
+	// A commit on another branch, dated before the scanned HEAD, while HEAD
+	// does not move.
+	run("", "checkout", "-q", "feature")
+	feature := commit("feature", "2020-06-01T00:00:00Z")
+	run("", "checkout", "-q", "main")
+
+	commits, err = commitsSince(ctx, dir, scanned)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{feature}, commits)
+
+	// After merging it, HEAD moved past a commit dated before the old HEAD.
+	run("2021-02-01T00:00:00Z", "merge", "-q", "--no-ff", "--no-edit", "feature")
+	merge := run("", "rev-parse", "HEAD")
+	commits, err = commitsSince(ctx, dir, scanned)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{feature, merge}, commits)
+
+	tips, err := refTips(repo)
+	assert.NoError(t, err)
+	commits, err = commitsSince(ctx, dir, tips)
+	assert.NoError(t, err)
+	assert.Empty(t, commits)
+}
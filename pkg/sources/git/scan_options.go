@@ -13,6 +13,9 @@ type ScanOptions struct {
 	Bare         bool
 	ExcludeGlobs []string
 	LogOptions   *git.LogOptions
+	// ExcludeCommits are commits whose history is not scanned: only commits
+	// that are reachable from none of them are.
+	ExcludeCommits []string
 }
 
 type ScanOption func(*ScanOptions)
@@ -35,6 +38,12 @@ func ScanOptionHeadCommit(hash string) ScanOption {
 	}
 }
 
+func ScanOptionExcludeCommits(hashes []string) ScanOption {
+	return func(scanOptions *ScanOptions) {
+		scanOptions.ExcludeCommits = hashes
+	}
+}
+
 func ScanOptionMaxDepth(maxDepth int64) ScanOption {
 	return func(scanOptions *ScanOptions) {
 		scanOptions.MaxDepth = maxDepth
@@ -14,11 +14,13 @@ import (
 	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/giturl"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/membudget"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/git"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/statestore"
 
 	gogit "github.com/go-git/go-git/v5"
 	"github.com/gobwas/glob"
@@ -55,6 +57,8 @@ type Source struct {
 	useCustomContentWriter bool
 	git                    *git.Git
 	scanOptions            *git.ScanOptions
+	stateStore             statestore.Store
+	memBudget              *membudget.Budget
 
 	resumeInfoSlice []string
 	resumeInfoMutex sync.Mutex
@@ -67,6 +71,14 @@ type Source struct {
 // WithCustomContentWriter sets the useCustomContentWriter flag on the source.
 func (s *Source) WithCustomContentWriter() { s.useCustomContentWriter = true }
 
+// WithStateStore makes the source only scan the commits added to each project
+// since the last scan recorded in store.
+func (s *Source) WithStateStore(store statestore.Store) { s.stateStore = store }
+
+// WithMemoryBudget makes the source reserve the size of every chunk in b
+// before sending it, blocking while the budget is used up.
+func (s *Source) WithMemoryBudget(b *membudget.Budget) { s.memBudget = b }
+
 // Ensure the Source satisfies the interfaces at compile time.
 var _ sources.Source = (*Source)(nil)
 var _ sources.SourceUnitUnmarshaller = (*Source)(nil)
@@ -215,6 +227,7 @@ func (s *Source) Init(ctx context.Context, name string, jobId sources.JobID, sou
 			}
 		},
 		UseCustomContentWriter: s.useCustomContentWriter,
+		StateStore:             s.stateStore,
 	}
 	s.git = git.NewGit(cfg)
 
@@ -664,7 +677,7 @@ func (s *Source) scanRepos(ctx context.Context, chunksChan chan *sources.Chunk)
 			defer os.RemoveAll(path)
 
 			logger.V(2).Info("starting scan", "num", i+1, "total", len(s.repos))
-			if err = s.git.ScanRepo(ctx, repo, path, s.scanOptions, sources.ChanReporter{Ch: chunksChan}); err != nil {
+			if err = s.git.ScanRepo(ctx, repo, path, s.scanOptions, s.memBudget.ChanReporter(chunksChan)); err != nil {
 				scanErrs.Add(err)
 				return nil
 			}
@@ -20,6 +20,7 @@ import (
 	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/giturl"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/membudget"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
@@ -50,6 +51,7 @@ type Source struct {
 	jobID                  sources.JobID
 	verify                 bool
 	useCustomContentWriter bool
+	memBudget              *membudget.Budget
 	orgsCache              cache.Cache[string]
 	usersCache             cache.Cache[string]
 
@@ -91,6 +93,10 @@ var _ sources.SourceUnitUnmarshaller = (*Source)(nil)
 // WithCustomContentWriter sets the useCustomContentWriter flag on the source.
 func (s *Source) WithCustomContentWriter() { s.useCustomContentWriter = true }
 
+// WithMemoryBudget makes the source reserve the size of every chunk in b
+// before sending it, blocking while the budget is used up.
+func (s *Source) WithMemoryBudget(b *membudget.Budget) { s.memBudget = b }
+
 // Type returns the type of source.
 // It is used for matching source types in configuration and job input.
 func (s *Source) Type() sourcespb.SourceType {
@@ -608,7 +614,7 @@ func (s *Source) cloneAndScanRepo(ctx context.Context, repoURL string, repoInfo
 	logger.V(2).Info("scanning %s", repoInfo.resourceType)
 
 	start := time.Now()
-	if err = s.git.ScanRepo(ctx, repo, path, s.scanOptions, sources.ChanReporter{Ch: chunksChan}); err != nil {
+	if err = s.git.ScanRepo(ctx, repo, path, s.scanOptions, s.memBudget.ChanReporter(chunksChan)); err != nil {
 		return 0, fmt.Errorf("error scanning repo %s: %w", repoURL, err)
 	}
 	return time.Since(start), nil
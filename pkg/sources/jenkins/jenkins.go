@@ -4,9 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
@@ -22,6 +24,7 @@ import (
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/roundtripper"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/statestore"
 )
 
 const (
@@ -40,6 +43,8 @@ type Source struct {
 	log      logr.Logger
 	client   *http.Client
 	sources.Progress
+
+	stateStore statestore.Store
 }
 
 type header struct {
@@ -56,6 +61,10 @@ func (s *Source) Type() sourcespb.SourceType {
 	return sourcespb.SourceType_SOURCE_TYPE_JENKINS
 }
 
+// WithStateStore makes the source only scan the builds of each project that
+// are newer than the last scan recorded in store.
+func (s *Source) WithStateStore(store statestore.Store) { s.stateStore = store }
+
 func (s *Source) SourceID() sources.SourceID {
 	return s.sourceId
 }
@@ -259,7 +268,7 @@ func (s *Source) GetJenkinsBuilds(ctx context.Context, jobAbsolutePath string) (
 	buildsUrl := *s.url
 	for i := 0; true; i += 100 {
 		buildsUrl.Path = path.Join(jobAbsolutePath, "/api/json")
-		buildsUrl.RawQuery = fmt.Sprintf("tree=builds[number,url]{%d,%d}", i, i+100)
+		buildsUrl.RawQuery = fmt.Sprintf("tree=builds[number,url,building]{%d,%d}", i, i+100)
 		req, err := s.NewRequest(http.MethodGet, buildsUrl.String(), nil)
 		if err != nil {
 			return builds, errors.WrapPrefix(err, "Failed to create new request to get jenkins builds", 0)
@@ -321,13 +330,20 @@ func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ .
 			continue
 		}
 
+		lastScanned := s.lastScannedBuild(ctx, projectURL.Path)
+		scanned := newBuildMarker(lastScanned)
 		for _, build := range builds.Builds {
 			if common.IsDone(ctx) {
 				return nil
 			}
+			if build.Number <= lastScanned {
+				continue
+			}
 
-			s.chunkBuild(ctx, build, project.Name, chunksChan)
+			ok := s.chunkBuild(ctx, build, project.Name, chunksChan)
+			scanned.add(build, ok)
 		}
+		s.recordScannedBuild(ctx, projectURL.Path, lastScanned, scanned.last())
 	}
 
 	s.SetProgressComplete(len(jobs.Jobs), len(jobs.Jobs), fmt.Sprintf("Done scanning source %s", s.name), "")
@@ -336,7 +352,8 @@ func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ .
 
 // chunkBuild takes build information and sends it to the chunksChan.
 // It also logs all errors that occur and does not return them, as the parent context expects to continue running.
-func (s *Source) chunkBuild(_ context.Context, build JenkinsBuild, projectName string, chunksChan chan *sources.Chunk) {
+// It reports whether the build log was sent.
+func (s *Source) chunkBuild(_ context.Context, build JenkinsBuild, projectName string, chunksChan chan *sources.Chunk) bool {
 	// Setup a logger to identify the build and project.
 	chunkBuildLog := s.log.WithValues(
 		"build", build.Number,
@@ -346,7 +363,7 @@ func (s *Source) chunkBuild(_ context.Context, build JenkinsBuild, projectName s
 	parsedUrl, err := url.Parse(build.Url)
 	if err != nil {
 		chunkBuildLog.Error(err, "Failed to parse Jenkins build URL, skipping build", "url", build.Url)
-		return
+		return false
 	}
 	buildLogURL := *s.url
 	buildLogURL.Path = path.Join(parsedUrl.Path, "consoleText")
@@ -354,27 +371,29 @@ func (s *Source) chunkBuild(_ context.Context, build JenkinsBuild, projectName s
 	req, err := s.NewRequest(http.MethodGet, buildLogURL.String(), nil)
 	if err != nil {
 		chunkBuildLog.Error(err, "Failed to create new request to Jenkins, skipping build")
-		return
+		return false
 	}
 
 	resp, err := s.client.Do(req)
 	if err != nil {
 		chunkBuildLog.Error(err, "Failed to get build log in Jenkins chunks, skipping build")
-		return
+		return false
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
 		chunkBuildLog.Error(err, "Status Code from build was unexpected, skipping build", "status_code", resp.StatusCode)
-		return
+		return false
 	}
 
 	buildLog, err := io.ReadAll(resp.Body)
 	if err != nil {
 		chunkBuildLog.Error(err, "Failed to read body from the build log response, skipping build")
-		return
+		return false
 	}
 
+	// The whole log is sent as one chunk, which lets the output report the
+	// line and offset of a finding within the log.
 	chunksChan <- &sources.Chunk{
 		SourceName: s.name,
 		SourceID:   s.SourceID(),
@@ -392,6 +411,7 @@ func (s *Source) chunkBuild(_ context.Context, build JenkinsBuild, projectName s
 		Data:   buildLog,
 		Verify: s.verify,
 	}
+	return true
 }
 
 type JenkinsJobResponse struct {
@@ -407,6 +427,61 @@ type JenkinsBuildResponse struct {
 }
 
 type JenkinsBuild struct {
-	Number int64  `json:"number"`
-	Url    string `json:"url"`
+	Number   int64  `json:"number"`
+	Url      string `json:"url"`
+	Building bool   `json:"building"`
+}
+
+// lastScannedBuild returns the number of the last build of the project at
+// projectPath that was scanned by a previous scan, or zero.
+func (s *Source) lastScannedBuild(ctx context.Context, projectPath string) int64 {
+	if s.stateStore == nil {
+		return 0
+	}
+	marker, ok, err := s.stateStore.Get(ctx, s.name, projectPath)
+	if err != nil {
+		s.log.V(2).Info("unable to read scan state", "project", projectPath, "error", err)
+		return 0
+	}
+	if !ok {
+		return 0
+	}
+	number, err := strconv.ParseInt(marker, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return number
+}
+
+func (s *Source) recordScannedBuild(ctx context.Context, projectPath string, previous, last int64) {
+	if s.stateStore == nil || last <= previous {
+		return
+	}
+	if err := s.stateStore.Set(ctx, s.name, projectPath, strconv.FormatInt(last, 10)); err != nil {
+		s.log.V(2).Info("unable to record scan state", "project", projectPath, "error", err)
+	}
+}
+
+// buildMarker computes the build number to record for a project: the highest
+// number below which every build was scanned and finished. Builds that failed
+// to scan or were still running are scanned again next time.
+type buildMarker struct {
+	highest       int64
+	lowestPending int64
+}
+
+func newBuildMarker(lastScanned int64) *buildMarker {
+	return &buildMarker{highest: lastScanned, lowestPending: math.MaxInt64}
+}
+
+func (m *buildMarker) add(build JenkinsBuild, scanned bool) {
+	if scanned && !build.Building {
+		m.highest = max(m.highest, build.Number)
+		return
+	}
+	m.lowestPending = min(m.lowestPending, build.Number)
+}
+
+func (m *buildMarker) last() int64 {
+	return min(m.highest, m.lowestPending-1)
 }
@@ -0,0 +1,16 @@
+package sources
+
+// ResumeInfo returns EncodedResumeInfo while holding the progress lock, so
+// it can be read while the source is running.
+func (p *Progress) ResumeInfo() string {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	return p.EncodedResumeInfo
+}
+
+// SetResumeInfo sets EncodedResumeInfo while holding the progress lock.
+func (p *Progress) SetResumeInfo(info string) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	p.EncodedResumeInfo = info
+}
@@ -0,0 +1,23 @@
+package stdin
+
+import (
+	"os"
+
+	"github.com/alecthomas/kingpin/v2"
+)
+
+var label *string
+
+// Command registers the stdin subcommand and its flags on app.
+func Command(app *kingpin.Application) *kingpin.CmdClause {
+	cli := app.Command("stdin", "Scan data piped into standard input, such as command output or a database dump.")
+	label = cli.Flag("label", "Name to report the data under in place of a file name.").Default(DefaultLabel).String()
+	return cli
+}
+
+// FromFlags returns a Source that reads standard input and labels its results
+// with --label. It is initialized with an empty name, so its results are
+// reported under SourceName.
+func FromFlags() *Source {
+	return New(os.Stdin, *label)
+}
@@ -0,0 +1,117 @@
+package stdin
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/handlers"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/membudget"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// SourceType is the type results from standard input are reported as. There
+// is no dedicated source type, so the stream is reported as a single file
+// named after the user supplied label, and SourceName tells its results apart
+// from those of filesystem scans.
+const SourceType = sourcespb.SourceType_SOURCE_TYPE_FILESYSTEM
+
+// SourceName is the source name results from standard input are reported
+// with, unless Init is given another one.
+const SourceName = "stdin"
+
+// DefaultLabel is used when no label is given.
+const DefaultLabel = "stdin"
+
+type Source struct {
+	name      string
+	sourceId  sources.SourceID
+	jobId     sources.JobID
+	verify    bool
+	label     string
+	reader    io.Reader
+	memBudget *membudget.Budget
+	sources.Progress
+}
+
+// Ensure the Source satisfies the interfaces at compile time.
+var _ sources.Source = (*Source)(nil)
+var _ membudget.Budgeted = (*Source)(nil)
+
+// WithMemoryBudget makes the source reserve the size of every chunk in b
+// before sending it, blocking while the budget is used up.
+func (s *Source) WithMemoryBudget(b *membudget.Budget) { s.memBudget = b }
+
+// New creates a Source that scans everything read from r and tags the
+// results with label. If r is nil, os.Stdin is read.
+func New(r io.Reader, label string) *Source {
+	if r == nil {
+		r = os.Stdin
+	}
+	if label == "" {
+		label = DefaultLabel
+	}
+	return &Source{reader: r, label: label}
+}
+
+// Type returns the type of source.
+// It is used for matching source types in configuration and job input.
+func (s *Source) Type() sourcespb.SourceType {
+	return SourceType
+}
+
+func (s *Source) SourceID() sources.SourceID {
+	return s.sourceId
+}
+
+func (s *Source) JobID() sources.JobID {
+	return s.jobId
+}
+
+// Init initializes the source. The stream has no connection settings, so
+// connection is ignored and may be nil. An empty name is replaced with
+// SourceName.
+func (s *Source) Init(_ context.Context, name string, jobId sources.JobID, sourceId sources.SourceID, verify bool, _ *anypb.Any, _ int) error {
+	if name == "" {
+		name = SourceName
+	}
+	s.name = name
+	s.sourceId = sourceId
+	s.jobId = jobId
+	s.verify = verify
+	return nil
+}
+
+// Chunks reads the stream to its end and passes it through the file
+// handlers, so archives and compressed data are unpacked before scanning.
+func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ ...sources.ChunkingTarget) error {
+	ctx = context.WithValues(ctx, "label", s.label)
+	ctx.Logger().V(2).Info("scanning standard input")
+
+	chunkSkel := &sources.Chunk{
+		SourceType: s.Type(),
+		SourceName: s.name,
+		SourceID:   s.SourceID(),
+		JobID:      s.JobID(),
+		SourceMetadata: &source_metadatapb.MetaData{
+			Data: &source_metadatapb.MetaData_Filesystem{
+				Filesystem: &source_metadatapb.Filesystem{
+					File: sanitizer.UTF8(s.label),
+				},
+			},
+		},
+		Verify: s.verify,
+	}
+
+	if err := handlers.HandleFile(ctx, io.NopCloser(s.reader), chunkSkel, s.memBudget.ChanReporter(chunksChan)); err != nil {
+		return fmt.Errorf("error scanning %s: %w", s.label, err)
+	}
+	s.SetProgressComplete(1, 1, fmt.Sprintf("Finished scanning %s", s.label), "")
+	return nil
+}
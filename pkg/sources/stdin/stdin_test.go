@@ -0,0 +1,33 @@
+package stdin
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+func TestSource_Chunks(t *testing.T) {
+	ctx := context.Background()
+	s := New(strings.NewReader("apiVersion: v1\nkind: Secret\n"), "kubectl")
+	require.NoError(t, s.Init(ctx, "", 1, 2, false, nil, 1))
+
+	chunksCh := make(chan *sources.Chunk, 1)
+	go func() {
+		defer close(chunksCh)
+		assert.NoError(t, s.Chunks(ctx, chunksCh))
+	}()
+
+	var data strings.Builder
+	for chunk := range chunksCh {
+		assert.Equal(t, "kubectl", chunk.SourceMetadata.GetFilesystem().GetFile())
+		assert.Equal(t, SourceName, chunk.SourceName)
+		assert.Equal(t, sources.JobID(1), chunk.JobID)
+		data.Write(chunk.Data)
+	}
+	assert.Equal(t, "apiVersion: v1\nkind: Secret\n", data.String())
+}
@@ -0,0 +1,21 @@
+package statestore
+
+import (
+	"github.com/alecthomas/kingpin/v2"
+)
+
+var path *string
+
+// Flags registers the state store flag on app.
+func Flags(app *kingpin.Application) {
+	path = app.Flag("state-store", "Only scan what changed since the last scan recorded in this file. Use a .db or .sqlite extension for a SQLite database.").String()
+}
+
+// FromFlags opens the store given by --state-store. It returns nil if the
+// flag is not set.
+func FromFlags() (Store, error) {
+	if *path == "" {
+		return nil, nil
+	}
+	return Open(*path)
+}
@@ -0,0 +1,128 @@
+package statestore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+// fileFormatVersion is the version of the JSON file format.
+const fileFormatVersion = 1
+
+type stateFile struct {
+	Version   int                          `json:"version"`
+	UpdatedAt time.Time                    `json:"updated_at"`
+	Sources   map[string]map[string]string `json:"sources"`
+}
+
+// FileStore keeps the markers in memory and writes them to a JSON file on
+// Commit.
+type FileStore struct {
+	filename string
+	staged   staged
+
+	mu      sync.RWMutex
+	sources map[string]map[string]string
+}
+
+// OpenFile opens the JSON store at filename. A missing file is treated as an
+// empty store and created on the first Commit.
+func OpenFile(filename string) (*FileStore, error) {
+	s := &FileStore{filename: filename, sources: make(map[string]map[string]string)}
+
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read state file: %w", err)
+	}
+	var f stateFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("unable to parse state file %s: %w", filename, err)
+	}
+	if f.Version != fileFormatVersion {
+		return nil, fmt.Errorf("unsupported state file version %d", f.Version)
+	}
+	for source, units := range f.Sources {
+		if units != nil {
+			s.sources[source] = units
+		}
+	}
+	return s, nil
+}
+
+func (s *FileStore) Get(_ context.Context, source, unit string) (string, bool, error) {
+	if marker, ok := s.staged.get(source, unit); ok {
+		return marker, true, nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	marker, ok := s.sources[source][unit]
+	return marker, ok, nil
+}
+
+func (s *FileStore) List(_ context.Context, source string) (map[string]string, error) {
+	s.mu.RLock()
+	markers := make(map[string]string, len(s.sources[source]))
+	for unit, marker := range s.sources[source] {
+		markers[unit] = marker
+	}
+	s.mu.RUnlock()
+
+	s.staged.overlay(source, markers)
+	return markers, nil
+}
+
+func (s *FileStore) Set(_ context.Context, source, unit, marker string) error {
+	s.staged.set(source, unit, marker)
+	return nil
+}
+
+func (s *FileStore) Commit(_ context.Context) error {
+	markers := s.staged.take()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, marker := range markers {
+		units, ok := s.sources[k.source]
+		if !ok {
+			units = make(map[string]string)
+			s.sources[k.source] = units
+		}
+		units[k.unit] = marker
+	}
+
+	data, err := json.MarshalIndent(stateFile{
+		Version:   fileFormatVersion,
+		UpdatedAt: time.Now().UTC(),
+		Sources:   s.sources,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.filename), ".state-*")
+	if err != nil {
+		return fmt.Errorf("unable to write state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("unable to write state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("unable to write state file: %w", err)
+	}
+	return os.Rename(tmp.Name(), s.filename)
+}
+
+func (s *FileStore) Close() error { return nil }
@@ -0,0 +1,131 @@
+package statestore
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS unit_state (
+	source     TEXT NOT NULL,
+	unit       TEXT NOT NULL,
+	marker     TEXT NOT NULL,
+	updated_at TEXT NOT NULL,
+	PRIMARY KEY (source, unit)
+);
+`
+
+// SQLiteStore keeps the markers in a SQLite database.
+type SQLiteStore struct {
+	db     *sql.DB
+	staged staged
+}
+
+// OpenSQLite opens the database at path, creating it and its schema if
+// necessary.
+func OpenSQLite(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open state database: %w", err)
+	}
+	// SQLite only supports a single writer.
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(schema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("unable to initialize state database: %w", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+func (s *SQLiteStore) Get(ctx context.Context, source, unit string) (string, bool, error) {
+	if marker, ok := s.staged.get(source, unit); ok {
+		return marker, true, nil
+	}
+	var marker string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT marker FROM unit_state WHERE source = ? AND unit = ?`, source, unit,
+	).Scan(&marker)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, unitError("read", source, unit, err)
+	}
+	return marker, true, nil
+}
+
+func (s *SQLiteStore) List(ctx context.Context, source string) (map[string]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT unit, marker FROM unit_state WHERE source = ?`, source)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list state of %s: %w", source, err)
+	}
+	defer rows.Close()
+
+	markers := make(map[string]string)
+	for rows.Next() {
+		var unit, marker string
+		if err := rows.Scan(&unit, &marker); err != nil {
+			return nil, fmt.Errorf("unable to list state of %s: %w", source, err)
+		}
+		markers[unit] = marker
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to list state of %s: %w", source, err)
+	}
+
+	s.staged.overlay(source, markers)
+	return markers, nil
+}
+
+func (s *SQLiteStore) Set(_ context.Context, source, unit, marker string) error {
+	s.staged.set(source, unit, marker)
+	return nil
+}
+
+func (s *SQLiteStore) Commit(ctx context.Context) error {
+	markers := s.staged.take()
+	if len(markers) == 0 {
+		return nil
+	}
+	if err := s.commit(ctx, markers); err != nil {
+		s.staged.restore(markers)
+		return err
+	}
+	return nil
+}
+
+func (s *SQLiteStore) commit(ctx context.Context, markers map[unitKey]string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO unit_state (source, unit, marker, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (source, unit) DO UPDATE SET
+			marker = excluded.marker,
+			updated_at = excluded.updated_at`)
+	if err != nil {
+		return fmt.Errorf("unable to prepare state update: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	for k, marker := range markers {
+		if _, err := stmt.ExecContext(ctx, k.source, k.unit, marker, now); err != nil {
+			return unitError("record", k.source, k.unit, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Close closes the underlying database.
+func (s *SQLiteStore) Close() error { return s.db.Close() }
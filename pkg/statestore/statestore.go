@@ -0,0 +1,121 @@
+// Package statestore remembers what each source unit looked like when it was
+// last scanned, so repeated scans only cover what changed since. What is
+// remembered is an opaque marker chosen by the source: the ref tips of a
+// repository, the MD5 of an object, the latest timestamp of an index or the
+// last build number of a job.
+package statestore
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+// Store holds the marker of every unit scanned by a previous run, keyed by
+// source name and unit.
+//
+// Markers set during a scan are staged until Commit is called, which must
+// happen only once the engine has processed every chunk of the scan.
+// Otherwise, an interrupted scan would record units as scanned whose chunks
+// never reached the detectors.
+type Store interface {
+	// Get returns the marker of unit, including staged ones.
+	Get(ctx context.Context, source, unit string) (string, bool, error)
+	// List returns the markers of all units of source, including staged
+	// ones.
+	List(ctx context.Context, source string) (map[string]string, error)
+	// Set stages the marker of unit.
+	Set(ctx context.Context, source, unit, marker string) error
+	// Commit persists the staged markers.
+	Commit(ctx context.Context) error
+	// Close releases the store. Markers staged since the last Commit are
+	// discarded.
+	Close() error
+}
+
+// Incremental is implemented by sources that skip content a previous scan
+// already covered. WithStateStore must be called before Init.
+type Incremental interface {
+	WithStateStore(store Store)
+}
+
+// Open opens the store at path. Paths ending in .db, .sqlite or .sqlite3
+// use a SQLite database, which suits sources with many units. Anything else
+// uses a JSON file.
+func Open(path string) (Store, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".db", ".sqlite", ".sqlite3":
+		return OpenSQLite(path)
+	default:
+		return OpenFile(path)
+	}
+}
+
+type unitKey struct {
+	source string
+	unit   string
+}
+
+// staged holds the markers set since the last commit.
+type staged struct {
+	mu      sync.Mutex
+	markers map[unitKey]string
+}
+
+func (s *staged) get(source, unit string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	marker, ok := s.markers[unitKey{source, unit}]
+	return marker, ok
+}
+
+func (s *staged) set(source, unit, marker string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.markers == nil {
+		s.markers = make(map[unitKey]string)
+	}
+	s.markers[unitKey{source, unit}] = marker
+}
+
+// overlay copies the staged markers of source into markers.
+func (s *staged) overlay(source string, markers map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, marker := range s.markers {
+		if k.source == source {
+			markers[k.unit] = marker
+		}
+	}
+}
+
+// take returns the staged markers and clears them.
+func (s *staged) take() map[unitKey]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	markers := s.markers
+	s.markers = nil
+	return markers
+}
+
+// restore stages markers again after a failed commit, unless they were set
+// anew in the meantime.
+func (s *staged) restore(markers map[unitKey]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.markers == nil {
+		s.markers = make(map[unitKey]string, len(markers))
+	}
+	for k, marker := range markers {
+		if _, ok := s.markers[k]; !ok {
+			s.markers[k] = marker
+		}
+	}
+}
+
+func unitError(action, source, unit string, err error) error {
+	return fmt.Errorf("unable to %s state of %s/%s: %w", action, source, unit, err)
+}
@@ -0,0 +1,59 @@
+package statestore
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+func TestStores(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+	}{
+		{name: "file", filename: "state.json"},
+		{name: "sqlite", filename: "state.db"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			path := filepath.Join(t.TempDir(), tt.filename)
+
+			store, err := Open(path)
+			require.NoError(t, err)
+			_, ok, err := store.Get(ctx, "src", "repo")
+			require.NoError(t, err)
+			assert.False(t, ok)
+
+			require.NoError(t, store.Set(ctx, "src", "repo", "abc"))
+			require.NoError(t, store.Set(ctx, "other", "repo", "def"))
+			marker, ok, err := store.Get(ctx, "src", "repo")
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, "abc", marker)
+			require.NoError(t, store.Commit(ctx))
+
+			// Markers staged after the last commit are discarded on close.
+			require.NoError(t, store.Set(ctx, "src", "repo", "uncommitted"))
+			require.NoError(t, store.Set(ctx, "src", "new", "uncommitted"))
+			require.NoError(t, store.Close())
+
+			store, err = Open(path)
+			require.NoError(t, err)
+			defer store.Close()
+			markers, err := store.List(ctx, "src")
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"repo": "abc"}, markers)
+
+			require.NoError(t, store.Set(ctx, "src", "new", "ghi"))
+			markers, err = store.List(ctx, "src")
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"repo": "abc", "new": "ghi"}, markers)
+		})
+	}
+}
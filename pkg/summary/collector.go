@@ -0,0 +1,221 @@
+package summary
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/output"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// Collector gathers the data for a Summary while a scan is running. It is a
+// sources.JobProgressHook, to be registered with sources.WithReportHook, and
+// a printer that counts each result before passing it to the output printer.
+type Collector struct {
+	sources.NoopHook
+
+	next output.Printer
+
+	mu        sync.Mutex
+	sources   map[string]*sourceStats
+	detectors map[string]*DetectorSummary
+	decoders  map[string]uint64
+	timedOut  uint64
+	timeouts  []Timeout
+}
+
+type sourceStats struct {
+	summary SourceSummary
+	units   map[string]*unitStats
+}
+
+type unitStats struct {
+	summary UnitSummary
+	started time.Time
+}
+
+// NewCollector creates an empty Collector in front of next. next may be nil
+// if the results are printed elsewhere.
+func NewCollector(next output.Printer) *Collector {
+	return &Collector{
+		next:      next,
+		sources:   make(map[string]*sourceStats),
+		detectors: make(map[string]*DetectorSummary),
+		decoders:  make(map[string]uint64),
+	}
+}
+
+func (c *Collector) StartUnitChunking(ref sources.JobProgressRef, unit sources.SourceUnit, start time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.unit(ref, unit).started = start
+}
+
+func (c *Collector) EndUnitChunking(ref sources.JobProgressRef, unit sources.SourceUnit, end time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	u := c.unit(ref, unit)
+	if !u.started.IsZero() {
+		u.summary.Duration += Duration(end.Sub(u.started))
+		u.started = time.Time{}
+	}
+}
+
+func (c *Collector) ReportChunk(ref sources.JobProgressRef, unit sources.SourceUnit, chunk *sources.Chunk) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var size uint64
+	if chunk != nil {
+		size = uint64(len(chunk.Data))
+	}
+	src := c.source(ref)
+	src.summary.Chunks++
+	src.summary.Bytes += size
+	if unit != nil {
+		u := c.unit(ref, unit)
+		u.summary.Chunks++
+		u.summary.Bytes += size
+	}
+}
+
+func (c *Collector) ReportError(ref sources.JobProgressRef, _ error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.source(ref).summary.Errors++
+}
+
+// Finish records the unit counts of the job once it is done.
+func (c *Collector) Finish(ref sources.JobProgressRef) {
+	snap := ref.Snapshot()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	src := c.source(ref)
+	src.summary.TotalUnits += snap.TotalUnits
+	src.summary.FinishedUnits += snap.FinishedUnits
+}
+
+// source and unit must be called with c.mu held.
+func (c *Collector) source(ref sources.JobProgressRef) *sourceStats {
+	src, ok := c.sources[ref.SourceName]
+	if !ok {
+		src = &sourceStats{
+			summary: SourceSummary{Name: ref.SourceName},
+			units:   make(map[string]*unitStats),
+		}
+		c.sources[ref.SourceName] = src
+	}
+	return src
+}
+
+func (c *Collector) unit(ref sources.JobProgressRef, unit sources.SourceUnit) *unitStats {
+	src := c.source(ref)
+	id, kind := unit.SourceUnitID()
+	u, ok := src.units[id]
+	if !ok {
+		u = &unitStats{summary: UnitSummary{ID: id, Kind: string(kind)}}
+		src.units[id] = u
+	}
+	return u
+}
+
+// Print counts a result and passes it to the next printer.
+func (c *Collector) Print(ctx context.Context, r *detectors.ResultWithMetadata) error {
+	c.count(r)
+	if c.next == nil {
+		return nil
+	}
+	return c.next.Print(ctx, r)
+}
+
+func (c *Collector) count(r *detectors.ResultWithMetadata) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	name := r.DetectorType.String()
+	d, ok := c.detectors[name]
+	if !ok {
+		d = &DetectorSummary{Name: name}
+		c.detectors[name] = d
+	}
+	switch {
+	case r.Verified:
+		d.Verified++
+	case r.VerificationError() != nil:
+		d.Unknown++
+	default:
+		d.Unverified++
+	}
+}
+
+// ReportDecoderHit records that decoder produced data from a chunk. The engine
+// calls it once for every chunk and decoder that decoded something, whether
+// or not the decoded data contains a finding.
+func (c *Collector) ReportDecoderHit(decoder detectorspb.DecoderType) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.decoders[decoder.String()]++
+}
+
+// RecordTimeout records work that exceeded its time budget.
+func (c *Collector) RecordTimeout(t Timeout) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.timedOut++
+	if len(c.timeouts) < maxTimeouts {
+		c.timeouts = append(c.timeouts, t)
+	}
+}
+
+// Summary combines the collected data with the engine metrics.
+func (c *Collector) Summary(m EngineMetrics) Summary {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var s Summary
+	s.withEngineMetrics(m)
+	s.TimedOut = c.timedOut
+	s.Timeouts = append([]Timeout(nil), c.timeouts...)
+
+	for _, src := range c.sources {
+		summary := src.summary
+		for _, u := range src.units {
+			summary.Units = append(summary.Units, u.summary)
+		}
+		sort.Slice(summary.Units, func(i, j int) bool { return summary.Units[i].ID < summary.Units[j].ID })
+		s.Sources = append(s.Sources, summary)
+	}
+	sort.Slice(s.Sources, func(i, j int) bool { return s.Sources[i].Name < s.Sources[j].Name })
+
+	for _, d := range c.detectors {
+		s.Detectors = append(s.Detectors, *d)
+		s.Verified += d.Verified
+		s.Unverified += d.Unverified
+		s.Unknown += d.Unknown
+	}
+	sort.Slice(s.Detectors, func(i, j int) bool {
+		a, b := s.Detectors[i], s.Detectors[j]
+		if a.Verified != b.Verified {
+			return a.Verified > b.Verified
+		}
+		return a.Name < b.Name
+	})
+
+	for name, n := range c.decoders {
+		s.DecoderHits = append(s.DecoderHits, DecoderHits{Name: name, Chunks: n})
+	}
+	sort.Slice(s.DecoderHits, func(i, j int) bool {
+		a, b := s.DecoderHits[i], s.DecoderHits[j]
+		if a.Chunks != b.Chunks {
+			return a.Chunks > b.Chunks
+		}
+		return a.Name < b.Name
+	})
+
+	return s
+}
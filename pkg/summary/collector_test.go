@@ -0,0 +1,106 @@
+package summary
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+type countingPrinter struct{ count int }
+
+func (p *countingPrinter) Print(context.Context, *detectors.ResultWithMetadata) error {
+	p.count++
+	return nil
+}
+
+func TestCollector(t *testing.T) {
+	ctx := context.Background()
+	next := &countingPrinter{}
+	c := NewCollector(next)
+	var _ sources.JobProgressHook = c
+
+	ref := sources.JobProgressRef{SourceName: "repo"}
+	unit := sources.CommonSourceUnit{ID: "https://github.com/org/repo"}
+	start := time.Now()
+	c.StartUnitChunking(ref, unit, start)
+	c.ReportChunk(ref, unit, &sources.Chunk{Data: []byte("hello")})
+	c.ReportChunk(ref, unit, &sources.Chunk{Data: []byte("world!")})
+	c.EndUnitChunking(ref, unit, start.Add(2*time.Second))
+	c.ReportError(ref, errors.New("clone failed"))
+
+	aws := detectors.Result{DetectorType: detectorspb.DetectorType_AWS, Verified: true}
+	unknown := detectors.Result{DetectorType: detectorspb.DetectorType_AWS}
+	unknown.SetVerificationError(errors.New("timeout"))
+	slack := detectors.Result{DetectorType: detectorspb.DetectorType_Slack}
+	for _, r := range []detectors.Result{aws, unknown, slack} {
+		require.NoError(t, c.Print(ctx, &detectors.ResultWithMetadata{Result: r}))
+	}
+	assert.Equal(t, 3, next.count, "results must reach the next printer")
+	c.ReportDecoderHit(detectorspb.DecoderType_BASE64)
+	c.ReportDecoderHit(detectorspb.DecoderType_BASE64)
+	c.ReportDecoderHit(detectorspb.DecoderType_UTF16)
+
+	s := c.Summary(EngineMetrics{
+		ChunksScanned:   2,
+		BytesScanned:    11,
+		AvgDetectorTime: map[string]time.Duration{"AWS": time.Millisecond, "Slack": time.Second},
+	})
+
+	require.Len(t, s.Sources, 1)
+	assert.Equal(t, uint64(11), s.Sources[0].Bytes)
+	assert.Equal(t, uint64(1), s.Sources[0].Errors)
+	require.Len(t, s.Sources[0].Units, 1)
+	assert.Equal(t, uint64(2), s.Sources[0].Units[0].Chunks)
+	assert.Equal(t, Duration(2*time.Second), s.Sources[0].Units[0].Duration)
+
+	assert.Equal(t, uint64(1), s.Verified)
+	assert.Equal(t, uint64(1), s.Unverified)
+	assert.Equal(t, uint64(1), s.Unknown)
+	require.Len(t, s.Detectors, 2)
+	assert.Equal(t, DetectorSummary{Name: "AWS", Verified: 1, Unknown: 1}, s.Detectors[0])
+	assert.Equal(t, []DecoderHits{
+		{Name: detectorspb.DecoderType_BASE64.String(), Chunks: 2},
+		{Name: detectorspb.DecoderType_UTF16.String(), Chunks: 1},
+	}, s.DecoderHits)
+	require.Len(t, s.SlowestDetectors, 2)
+	assert.Equal(t, "Slack", s.SlowestDetectors[0].Name)
+
+	var out bytes.Buffer
+	require.NoError(t, s.WriteJSON(&out))
+	var decoded Summary
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, s, decoded)
+
+	out.Reset()
+	require.NoError(t, s.WritePlain(&out))
+	assert.Contains(t, out.String(), "SLOWEST DETECTOR")
+}
+
+func TestCollectorTimeouts(t *testing.T) {
+	c := NewCollector(nil)
+	timeout := Timeout{Kind: TimeoutDetector, Source: "fs", Detector: "AWS", Location: "huge.min.js", Limit: Duration(time.Second)}
+	for i := 0; i < maxTimeouts+5; i++ {
+		c.RecordTimeout(timeout)
+	}
+
+	s := c.Summary(EngineMetrics{})
+	assert.Equal(t, uint64(maxTimeouts+5), s.TimedOut)
+	require.Len(t, s.Timeouts, maxTimeouts)
+	assert.Equal(t, timeout, s.Timeouts[0])
+
+	var out bytes.Buffer
+	require.NoError(t, s.WritePlain(&out))
+	assert.Contains(t, out.String(), "TIMED OUT (105)")
+	assert.Contains(t, out.String(), "huge.min.js")
+	assert.Contains(t, out.String(), "... and 5 more")
+}
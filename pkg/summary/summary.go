@@ -0,0 +1,223 @@
+// Package summary builds an end-of-scan report of what was scanned and found.
+package summary
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// slowestDetectors is the number of detectors listed in Summary.SlowestDetectors.
+const slowestDetectors = 10
+
+// maxTimeouts is the number of timeouts listed in Summary.Timeouts. A detector
+// that times out on every chunk would otherwise grow the summary without
+// bound; TimedOut still counts all of them.
+const maxTimeouts = 100
+
+// Summary is the end-of-scan report.
+type Summary struct {
+	ScanDuration  Duration `json:"scan_duration"`
+	BytesScanned  uint64   `json:"bytes_scanned"`
+	ChunksScanned uint64   `json:"chunks_scanned"`
+	Verified      uint64   `json:"verified_secrets"`
+	Unverified    uint64   `json:"unverified_secrets"`
+	Unknown       uint64   `json:"unknown_secrets"`
+
+	Sources          []SourceSummary   `json:"sources"`
+	Detectors        []DetectorSummary `json:"detectors"`
+	DecoderHits      []DecoderHits     `json:"decoder_hits"`
+	SlowestDetectors []DetectorTiming  `json:"slowest_detectors"`
+
+	// TimedOut is the number of pieces of work that exceeded their time
+	// budget. Timeouts lists the first of them.
+	TimedOut uint64    `json:"timed_out"`
+	Timeouts []Timeout `json:"timeouts,omitempty"`
+}
+
+// SourceSummary describes the coverage of a single source.
+type SourceSummary struct {
+	Name          string        `json:"name"`
+	TotalUnits    uint64        `json:"total_units"`
+	FinishedUnits uint64        `json:"finished_units"`
+	Chunks        uint64        `json:"chunks"`
+	Bytes         uint64        `json:"bytes"`
+	Errors        uint64        `json:"errors"`
+	Units         []UnitSummary `json:"units"`
+}
+
+// UnitSummary describes the coverage of a single source unit.
+type UnitSummary struct {
+	ID       string   `json:"id"`
+	Kind     string   `json:"kind,omitempty"`
+	Chunks   uint64   `json:"chunks"`
+	Bytes    uint64   `json:"bytes"`
+	Duration Duration `json:"duration"`
+}
+
+// DetectorSummary counts the findings of a single detector. Unknown results
+// are the ones whose verification failed with an error.
+type DetectorSummary struct {
+	Name       string `json:"name"`
+	Verified   uint64 `json:"verified"`
+	Unverified uint64 `json:"unverified"`
+	Unknown    uint64 `json:"unknown"`
+}
+
+// DecoderHits counts the chunks a single decoder produced data from.
+type DecoderHits struct {
+	Name   string `json:"name"`
+	Chunks uint64 `json:"chunks"`
+}
+
+// DetectorTiming is the average time a detector spent per chunk.
+type DetectorTiming struct {
+	Name    string   `json:"name"`
+	AvgTime Duration `json:"avg_time"`
+}
+
+// Kinds of work a Timeout can refer to.
+const (
+	TimeoutDetector = "detector"
+	TimeoutUnit     = "unit"
+	TimeoutScan     = "scan"
+)
+
+// Timeout is a piece of work that was abandoned because it exceeded its time
+// budget. Fields that do not apply to its kind are empty.
+type Timeout struct {
+	Kind     string `json:"kind"`
+	Source   string `json:"source,omitempty"`
+	Unit     string `json:"unit,omitempty"`
+	Detector string `json:"detector,omitempty"`
+	// Location is the most specific location of the chunk a detector timed
+	// out on, such as a file or a link.
+	Location string   `json:"location,omitempty"`
+	Limit    Duration `json:"limit"`
+}
+
+// Duration is a time.Duration that is encoded as a string such as "1.5s".
+type Duration time.Duration
+
+func (d Duration) String() string { return time.Duration(d).String() }
+
+func (d Duration) MarshalJSON() ([]byte, error) { return json.Marshal(d.String()) }
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// slowest returns the n detectors with the highest average time per chunk.
+func slowest(avg map[string]time.Duration, n int) []DetectorTiming {
+	timings := make([]DetectorTiming, 0, len(avg))
+	for name, d := range avg {
+		timings = append(timings, DetectorTiming{Name: name, AvgTime: Duration(d)})
+	}
+	sort.Slice(timings, func(i, j int) bool {
+		if timings[i].AvgTime != timings[j].AvgTime {
+			return timings[i].AvgTime > timings[j].AvgTime
+		}
+		return timings[i].Name < timings[j].Name
+	})
+	if len(timings) > n {
+		timings = timings[:n]
+	}
+	return timings
+}
+
+// EngineMetrics are the totals reported by the engine at the end of a scan.
+// The caller copies them from engine.Metrics, which this package does not
+// import so that the engine's dependencies can record into a Collector.
+type EngineMetrics struct {
+	ScanDuration    time.Duration
+	BytesScanned    uint64
+	ChunksScanned   uint64
+	AvgDetectorTime map[string]time.Duration
+}
+
+// withEngineMetrics fills in the totals reported by the engine.
+func (s *Summary) withEngineMetrics(m EngineMetrics) {
+	s.ScanDuration = Duration(m.ScanDuration)
+	s.BytesScanned = m.BytesScanned
+	s.ChunksScanned = m.ChunksScanned
+	s.SlowestDetectors = slowest(m.AvgDetectorTime, slowestDetectors)
+}
+
+// WriteJSON writes the summary as a single indented JSON document.
+func (s Summary) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(s); err != nil {
+		return fmt.Errorf("could not write scan summary: %w", err)
+	}
+	return nil
+}
+
+// WritePlain writes the summary as human readable tables.
+func (s Summary) WritePlain(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "Scan summary")
+	fmt.Fprintf(tw, "  Duration:\t%s\n", s.ScanDuration)
+	fmt.Fprintf(tw, "  Chunks scanned:\t%d\n", s.ChunksScanned)
+	fmt.Fprintf(tw, "  Bytes scanned:\t%d\n", s.BytesScanned)
+	fmt.Fprintf(tw, "  Verified secrets:\t%d\n", s.Verified)
+	fmt.Fprintf(tw, "  Unverified secrets:\t%d\n", s.Unverified)
+	fmt.Fprintf(tw, "  Unknown secrets (verification errors):\t%d\n", s.Unknown)
+
+	if len(s.Sources) > 0 {
+		fmt.Fprintln(tw, "\nSOURCE\tUNIT\tCHUNKS\tBYTES\tDURATION\tERRORS")
+		for _, src := range s.Sources {
+			fmt.Fprintf(tw, "%s\t%d/%d units\t%d\t%d\t\t%d\n",
+				src.Name, src.FinishedUnits, src.TotalUnits, src.Chunks, src.Bytes, src.Errors)
+			for _, u := range src.Units {
+				fmt.Fprintf(tw, "\t%s\t%d\t%d\t%s\t\n", u.ID, u.Chunks, u.Bytes, u.Duration)
+			}
+		}
+	}
+
+	if len(s.Detectors) > 0 {
+		fmt.Fprintln(tw, "\nDETECTOR\tVERIFIED\tUNVERIFIED\tUNKNOWN")
+		for _, d := range s.Detectors {
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%d\n", d.Name, d.Verified, d.Unverified, d.Unknown)
+		}
+	}
+
+	if len(s.DecoderHits) > 0 {
+		fmt.Fprintln(tw, "\nDECODER\tCHUNKS DECODED")
+		for _, d := range s.DecoderHits {
+			fmt.Fprintf(tw, "%s\t%d\n", d.Name, d.Chunks)
+		}
+	}
+
+	if len(s.SlowestDetectors) > 0 {
+		fmt.Fprintln(tw, "\nSLOWEST DETECTOR\tAVG TIME PER CHUNK")
+		for _, d := range s.SlowestDetectors {
+			fmt.Fprintf(tw, "%s\t%s\n", d.Name, d.AvgTime)
+		}
+	}
+
+	if s.TimedOut > 0 {
+		fmt.Fprintf(tw, "\nTIMED OUT (%d)\tLIMIT\tSOURCE\tUNIT\tDETECTOR\tLOCATION\n", s.TimedOut)
+		for _, t := range s.Timeouts {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", t.Kind, t.Limit, t.Source, t.Unit, t.Detector, t.Location)
+		}
+		if extra := s.TimedOut - uint64(len(s.Timeouts)); extra > 0 {
+			fmt.Fprintf(tw, "... and %d more\n", extra)
+		}
+	}
+
+	return tw.Flush()
+}
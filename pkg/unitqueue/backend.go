@@ -0,0 +1,49 @@
+// Package unitqueue distributes the units of a sources.SourceUnitEnumChunker
+// across processes. A coordinator enumerates the units into a queue, any
+// number of workers pull units from it and chunk them, and the workers report
+// their results and completed units back to the coordinator.
+package unitqueue
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+// ErrEmpty is returned by Backend.Pop if no message arrived in time.
+var ErrEmpty = errors.New("queue is empty")
+
+// Backend is a set of named FIFO lists of opaque messages shared by the
+// coordinator and its workers. Each message is delivered to exactly one Pop.
+type Backend interface {
+	Push(ctx context.Context, list string, msg []byte) error
+	// Pop removes and returns the first message of list, waiting up to
+	// timeout for one to arrive. It returns ErrEmpty if none did.
+	Pop(ctx context.Context, list string, timeout time.Duration) ([]byte, error)
+	Close() error
+}
+
+// Open creates the backend described by uri. redis:// and rediss:// URIs
+// connect to a server speaking the Redis protocol. file:// URIs and plain
+// paths use a directory, which may be on a shared file system.
+func Open(uri string) (Backend, error) {
+	if !strings.Contains(uri, "://") {
+		return NewFileBackend(uri)
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid queue URI: %w", err)
+	}
+	switch u.Scheme {
+	case "redis", "rediss":
+		return NewRedisBackend(uri)
+	case "file":
+		return NewFileBackend(u.Path)
+	default:
+		return nil, fmt.Errorf("unsupported queue scheme %q", u.Scheme)
+	}
+}
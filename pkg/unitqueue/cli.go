@@ -0,0 +1,68 @@
+package unitqueue
+
+import (
+	"errors"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+)
+
+// Roles a process can take in a distributed scan.
+const (
+	RoleCoordinator = "coordinator"
+	RoleWorker      = "worker"
+)
+
+var (
+	queueURI          *string
+	role              *string
+	runID             *string
+	idleTimeout       *time.Duration
+	visibilityTimeout *time.Duration
+	includeSecrets    *bool
+)
+
+// Flags registers the distributed scanning flags on app. They apply to every
+// source whose units can be enumerated.
+func Flags(app *kingpin.Application) {
+	queueURI = app.Flag("unit-queue", "Distribute source units through this queue: a directory, file:// or redis:// URI.").String()
+	role = app.Flag("unit-queue-role", "Enumerate units into the queue, or scan units from it.").Default(RoleCoordinator).Enum(RoleCoordinator, RoleWorker)
+	runID = app.Flag("unit-queue-run", "Run ID printed by the coordinator. Required for workers.").String()
+	idleTimeout = app.Flag("unit-queue-idle-timeout", "How long a worker waits for new units once the queue is empty.").Default("1m").Duration()
+	visibilityTimeout = app.Flag("unit-queue-visibility-timeout", "How long a worker may stay silent before its units are given to other workers. Must be the same for the coordinator and its workers.").Default(defaultVisibilityTimeout.String()).Duration()
+	includeSecrets = app.Flag("unit-queue-include-secrets", "Send raw secrets from workers through the queue. Results are redacted by default.").Bool()
+}
+
+// Settings is the distributed scanning configuration given on the command
+// line.
+type Settings struct {
+	Backend           Backend
+	Role              string
+	RunID             string
+	IdleTimeout       time.Duration
+	VisibilityTimeout time.Duration
+	IncludeSecrets    bool
+}
+
+// SettingsFromFlags opens the queue given by --unit-queue. It returns nil if
+// the scan is not distributed.
+func SettingsFromFlags() (*Settings, error) {
+	if *queueURI == "" {
+		return nil, nil
+	}
+	if *role == RoleWorker && *runID == "" {
+		return nil, errors.New("--unit-queue-run is required for workers")
+	}
+	backend, err := Open(*queueURI)
+	if err != nil {
+		return nil, err
+	}
+	return &Settings{
+		Backend:           backend,
+		Role:              *role,
+		RunID:             *runID,
+		IdleTimeout:       *idleTimeout,
+		VisibilityTimeout: *visibilityTimeout,
+		IncludeSecrets:    *includeSecrets,
+	}, nil
+}
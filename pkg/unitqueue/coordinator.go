@@ -0,0 +1,310 @@
+package unitqueue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/output"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+const (
+	// collectPollTimeout bounds how long Collect blocks on the backend before
+	// checking its context and the workers' leases again.
+	collectPollTimeout = 5 * time.Second
+	// defaultVisibilityTimeout is how long a worker may stay silent before its
+	// units are redelivered.
+	defaultVisibilityTimeout = 5 * time.Minute
+)
+
+// Coordinator enumerates the units of a source into the queue and collects
+// what the workers report back.
+type Coordinator struct {
+	backend           Backend
+	source            string
+	run               string
+	visibilityTimeout time.Duration
+
+	mu       sync.Mutex
+	tasks    map[string][]byte // task ID -> message, kept for redelivery
+	enumErrs []error
+}
+
+// NewCoordinator creates a Coordinator for the source named source. Workers
+// must be configured with the same name and with the coordinator's RunID.
+func NewCoordinator(backend Backend, source string) (*Coordinator, error) {
+	run, err := newRunID()
+	if err != nil {
+		return nil, fmt.Errorf("unable to create run ID: %w", err)
+	}
+	return &Coordinator{
+		backend:           backend,
+		source:            source,
+		run:               run,
+		visibilityTimeout: defaultVisibilityTimeout,
+		tasks:             make(map[string][]byte),
+	}, nil
+}
+
+// WithVisibilityTimeout sets how long a worker may go without reporting
+// before the units it claimed are put back into the queue. It must match the
+// workers' VisibilityTimeout.
+func (c *Coordinator) WithVisibilityTimeout(d time.Duration) {
+	if d > 0 {
+		c.visibilityTimeout = d
+	}
+}
+
+// RunID returns the ID of this run. Units and reports of other runs sharing
+// the backend are never seen by it.
+func (c *Coordinator) RunID() string { return c.run }
+
+// Enumerate enumerates the units of src into the queue and returns how many
+// were enqueued. Errors reported by the enumeration are returned by Collect.
+func (c *Coordinator) Enumerate(ctx context.Context, src sources.SourceUnitEnumerator) (int, error) {
+	ctx = context.WithValues(ctx, "run", c.run)
+	if err := src.Enumerate(ctx, c); err != nil {
+		return c.Units(), fmt.Errorf("unable to enumerate units: %w", err)
+	}
+	return c.Units(), nil
+}
+
+// Units returns the number of units enqueued so far.
+func (c *Coordinator) Units() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.tasks)
+}
+
+// UnitOk implements sources.UnitReporter by enqueueing the unit.
+func (c *Coordinator) UnitOk(ctx context.Context, unit sources.SourceUnit) error {
+	data, err := json.Marshal(unit)
+	if err != nil {
+		return fmt.Errorf("could not marshal unit %s: %w", unit.Display(), err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := task{ID: fmt.Sprintf("%s-%s-%d", c.run, c.source, len(c.tasks)+1), Source: c.source, Unit: data}
+	msg, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	if err := c.backend.Push(ctx, runList(unitsList, c.run), msg); err != nil {
+		return fmt.Errorf("unable to enqueue unit %s: %w", unit.Display(), err)
+	}
+	c.tasks[t.ID] = msg
+	return nil
+}
+
+// UnitErr implements sources.UnitReporter.
+func (c *Coordinator) UnitErr(_ context.Context, err error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.enumErrs = append(c.enumErrs, err)
+	return nil
+}
+
+// workerState is what Collect knows about a worker.
+type workerState struct {
+	running  bool
+	dead     bool
+	lastSeen time.Time
+	// claimed holds the units the worker took since it started, including
+	// those it finished, because their results may still be on the way.
+	claimed map[string]struct{}
+}
+
+// collectState tracks a running Collect.
+type collectState struct {
+	pending   map[string]struct{} // units that are not done
+	claimedBy map[string]string   // pending unit -> worker
+	workers   map[string]*workerState
+	running   int
+	// idleSince is when the last running worker stopped, or when Collect
+	// started if none ran yet.
+	idleSince time.Time
+}
+
+// ErrNoWorkers is returned by Collect if units are pending but no worker ran
+// for the visibility timeout.
+var ErrNoWorkers = errors.New("no worker is running")
+
+// Collect passes the results reported by the workers to onResult until every
+// enqueued unit is done and every worker that took part has stopped, so that
+// results found after a unit finished chunking are not lost. It must be called
+// after Enumerate returned and returns the errors of the enumeration and of
+// all units joined.
+//
+// A worker that does not report for the visibility timeout is considered
+// dead: the units it claimed are put back into the queue for other workers,
+// and its later reports are ignored. Results are delivered at least once, so
+// results of a dead worker's units may be reported twice. If units are
+// pending and no worker runs for the visibility timeout, whether none started
+// or all of them stopped, Collect gives up and returns ErrNoWorkers.
+func (c *Coordinator) Collect(ctx context.Context, onResult func(output.ResultRecord) error) error {
+	ctx = context.WithValues(ctx, "run", c.run)
+	c.mu.Lock()
+	st := &collectState{
+		pending:   make(map[string]struct{}, len(c.tasks)),
+		claimedBy: make(map[string]string),
+		workers:   make(map[string]*workerState),
+		idleSince: time.Now(),
+	}
+	for id := range c.tasks {
+		st.pending[id] = struct{}{}
+	}
+	errs := append([]error(nil), c.enumErrs...)
+	c.mu.Unlock()
+
+	pollTimeout := min(collectPollTimeout, c.visibilityTimeout)
+	var chunks uint64
+	for len(st.pending) > 0 || st.running > 0 {
+		if err := c.expireWorkers(ctx, st, time.Now()); err != nil {
+			return err
+		}
+
+		msg, err := c.backend.Pop(ctx, runList(reportsList, c.run), pollTimeout)
+		if errors.Is(err, ErrEmpty) {
+			if st.running == 0 && time.Since(st.idleSince) >= c.visibilityTimeout {
+				errs = append(errs, fmt.Errorf("%w: %d units were not scanned", ErrNoWorkers, len(st.pending)))
+				return errors.Join(errs...)
+			}
+			ctx.Logger().V(2).Info("waiting for workers", "pending_units", len(st.pending), "running_workers", st.running)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read reports: %w", err)
+		}
+
+		var r report
+		if err := json.Unmarshal(msg, &r); err != nil {
+			ctx.Logger().Error(err, "skipping malformed report")
+			continue
+		}
+		if r.TaskID != "" && !c.known(r.TaskID) {
+			ctx.Logger().V(1).Info("ignoring report for an unknown unit", "worker", r.Worker, "task", r.TaskID)
+			continue
+		}
+		w := st.workers[r.Worker]
+		if w == nil {
+			w = &workerState{claimed: make(map[string]struct{})}
+			st.workers[r.Worker] = w
+		}
+		if w.dead {
+			ctx.Logger().V(1).Info("ignoring report of a worker considered dead", "worker", r.Worker, "kind", r.Kind)
+			continue
+		}
+		w.lastSeen = time.Now()
+		if !w.running && r.Kind != reportStopped {
+			w.running = true
+			st.running++
+		}
+
+		switch r.Kind {
+		case reportStopped:
+			if w.running {
+				w.running = false
+				st.running--
+				if st.running == 0 {
+					st.idleSince = time.Now()
+				}
+			}
+			w.claimed = make(map[string]struct{})
+			if r.Idle {
+				// The queue stayed empty before the worker stopped, so
+				// pending units that nobody claimed were lost by a worker
+				// that died between taking them and claiming them.
+				if err := c.redeliverUnclaimed(ctx, st); err != nil {
+					return err
+				}
+			}
+		case reportClaimed:
+			w.claimed[r.TaskID] = struct{}{}
+			if _, ok := st.pending[r.TaskID]; ok {
+				st.claimedBy[r.TaskID] = r.Worker
+			}
+		case reportDone:
+			if _, ok := st.pending[r.TaskID]; !ok {
+				continue
+			}
+			delete(st.pending, r.TaskID)
+			delete(st.claimedBy, r.TaskID)
+			chunks += r.Chunks
+			for _, e := range r.Errors {
+				errs = append(errs, fmt.Errorf("%s: %s", r.TaskID, e))
+			}
+		case reportResult:
+			if r.Result == nil {
+				continue
+			}
+			if err := onResult(*r.Result); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	ctx.Logger().V(1).Info("distributed scan finished", "workers", len(st.workers), "chunks", chunks)
+	return errors.Join(errs...)
+}
+
+// known reports whether id is a unit of this run.
+func (c *Coordinator) known(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.tasks[id]
+	return ok
+}
+
+// expireWorkers declares running workers that did not report for the
+// visibility timeout dead and redelivers the units they claimed.
+func (c *Coordinator) expireWorkers(ctx context.Context, st *collectState, now time.Time) error {
+	for id, w := range st.workers {
+		if !w.running || now.Sub(w.lastSeen) < c.visibilityTimeout {
+			continue
+		}
+		ctx.Logger().Info("worker stopped reporting, redelivering its units", "worker", id, "units", len(w.claimed))
+		w.running, w.dead = false, true
+		st.running--
+		if st.running == 0 {
+			st.idleSince = now
+		}
+		for taskID := range w.claimed {
+			st.pending[taskID] = struct{}{}
+			delete(st.claimedBy, taskID)
+			if err := c.redeliver(ctx, taskID); err != nil {
+				return err
+			}
+		}
+		w.claimed = nil
+	}
+	return nil
+}
+
+// redeliverUnclaimed puts the pending units that no worker claimed back into
+// the queue.
+func (c *Coordinator) redeliverUnclaimed(ctx context.Context, st *collectState) error {
+	for taskID := range st.pending {
+		if _, ok := st.claimedBy[taskID]; ok {
+			continue
+		}
+		ctx.Logger().Info("redelivering unclaimed unit", "task", taskID)
+		if err := c.redeliver(ctx, taskID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Coordinator) redeliver(ctx context.Context, taskID string) error {
+	c.mu.Lock()
+	msg := c.tasks[taskID]
+	c.mu.Unlock()
+	if err := c.backend.Push(ctx, runList(unitsList, c.run), msg); err != nil {
+		return fmt.Errorf("unable to redeliver unit %s: %w", taskID, err)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package unitqueue
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+const (
+	messageExt   = ".msg"
+	filePollRate = 100 * time.Millisecond
+)
+
+// FileBackend stores each list as a directory with one file per message.
+// Messages are written to a temporary name and renamed into place, and are
+// claimed by renaming them again, so processes sharing the directory never
+// see partial messages or receive the same message twice.
+type FileBackend struct {
+	dir string
+}
+
+// NewFileBackend creates a FileBackend in dir, creating it if needed.
+func NewFileBackend(dir string) (*FileBackend, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("unable to create queue directory: %w", err)
+	}
+	return &FileBackend{dir: dir}, nil
+}
+
+func (b *FileBackend) Push(_ context.Context, list string, msg []byte) error {
+	listDir, err := b.listDir(list)
+	if err != nil {
+		return err
+	}
+	// The name sorts by enqueue time; the random suffix keeps names unique
+	// across processes.
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%020d-%s", time.Now().UnixNano(), hex.EncodeToString(suffix))
+
+	tmp := filepath.Join(listDir, "."+name+".tmp")
+	if err := os.WriteFile(tmp, msg, 0o600); err != nil {
+		return fmt.Errorf("unable to write message: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(listDir, name+messageExt)); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("unable to enqueue message: %w", err)
+	}
+	return nil
+}
+
+func (b *FileBackend) Pop(ctx context.Context, list string, timeout time.Duration) ([]byte, error) {
+	listDir, err := b.listDir(list)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		msg, err := b.claim(listDir)
+		if !errors.Is(err, ErrEmpty) || !time.Now().Before(deadline) {
+			return msg, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(filePollRate):
+		}
+	}
+}
+
+// claim takes the oldest message of the list that no other process claimed
+// first. The message is renamed before it is read, because only the rename
+// decides which process gets it; if the process dies in between, the message
+// is left under its claimed name and the coordinator redelivers the unit from
+// its own copy once the visibility timeout passes.
+func (b *FileBackend) claim(listDir string) ([]byte, error) {
+	entries, err := os.ReadDir(listDir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read queue: %w", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), messageExt) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		path := filepath.Join(listDir, name)
+		claimed := filepath.Join(listDir, "."+name+fmt.Sprintf(".claimed-%d", os.Getpid()))
+		if err := os.Rename(path, claimed); err != nil {
+			// Another process claimed it first.
+			continue
+		}
+		msg, err := os.ReadFile(claimed)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read message: %w", err)
+		}
+		_ = os.Remove(claimed)
+		return msg, nil
+	}
+	return nil, ErrEmpty
+}
+
+func (b *FileBackend) listDir(list string) (string, error) {
+	dir := filepath.Join(b.dir, list)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("unable to create queue list: %w", err)
+	}
+	return dir, nil
+}
+
+func (b *FileBackend) Close() error { return nil }
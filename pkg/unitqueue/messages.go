@@ -0,0 +1,71 @@
+package unitqueue
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/output"
+)
+
+// Names of the lists in the backend. Every run has its own lists, see
+// runList.
+const (
+	unitsList   = "units"
+	reportsList = "reports"
+)
+
+// validRunID matches the run IDs that are safe to use in list names.
+var validRunID = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// runList returns the name of list for run, so that units and reports left
+// over from an earlier run are never mixed into a new one.
+func runList(list, run string) string { return list + "-" + run }
+
+// newRunID returns a random run ID.
+func newRunID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// task is a unit waiting to be scanned by a worker.
+type task struct {
+	ID     string          `json:"id"`
+	Source string          `json:"source"`
+	Unit   json.RawMessage `json:"unit"`
+}
+
+type reportKind string
+
+const (
+	// reportStarted and reportStopped bracket the life of a worker, so the
+	// coordinator knows when no more results can arrive.
+	reportStarted reportKind = "started"
+	reportStopped reportKind = "stopped"
+	// reportHeartbeat is sent periodically while a worker runs, so the
+	// coordinator can tell a busy worker from a dead one.
+	reportHeartbeat reportKind = "heartbeat"
+	// reportClaimed is sent when a worker took a unit from the queue.
+	reportClaimed reportKind = "claimed"
+	// reportDone is sent after a worker chunked a unit.
+	reportDone reportKind = "done"
+	// reportResult carries a result found by a worker.
+	reportResult reportKind = "result"
+)
+
+// report is a message from a worker to the coordinator.
+type report struct {
+	Kind   reportKind           `json:"kind"`
+	Worker string               `json:"worker"`
+	TaskID string               `json:"task_id,omitempty"`
+	Chunks uint64               `json:"chunks,omitempty"`
+	Errors []string             `json:"errors,omitempty"`
+	Result *output.ResultRecord `json:"result,omitempty"`
+	// Idle is set on reportStopped if the worker stopped because the queue
+	// stayed empty.
+	Idle bool `json:"idle,omitempty"`
+}
@@ -0,0 +1,57 @@
+package unitqueue
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+// redisKeyPrefix namespaces the lists in the Redis keyspace.
+const redisKeyPrefix = "trufflehog:unitqueue:"
+
+// RedisBackend stores each list as a Redis list. Any server speaking the
+// Redis protocol and supporting RPUSH and BLPOP can be used.
+type RedisBackend struct {
+	client *redis.Client
+}
+
+// NewRedisBackend connects to the server at uri, for example
+// redis://:password@localhost:6379/0.
+func NewRedisBackend(uri string) (*RedisBackend, error) {
+	opts, err := redis.ParseURL(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis URI: %w", err)
+	}
+	client := redis.NewClient(opts)
+	if err := client.Ping().Err(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("unable to connect to redis: %w", err)
+	}
+	return &RedisBackend{client: client}, nil
+}
+
+func (b *RedisBackend) Push(ctx context.Context, list string, msg []byte) error {
+	return b.client.WithContext(ctx).RPush(redisKeyPrefix+list, msg).Err()
+}
+
+func (b *RedisBackend) Pop(ctx context.Context, list string, timeout time.Duration) ([]byte, error) {
+	// BLPOP treats a zero timeout as "block forever".
+	if timeout < time.Second {
+		timeout = time.Second
+	}
+	res, err := b.client.WithContext(ctx).BLPop(timeout, redisKeyPrefix+list).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrEmpty
+	}
+	if err != nil {
+		return nil, err
+	}
+	// The reply is the key followed by the value.
+	return []byte(res[1]), nil
+}
+
+func (b *RedisBackend) Close() error { return b.client.Close() }
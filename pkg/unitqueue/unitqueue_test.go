@@ -0,0 +1,299 @@
+package unitqueue
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/output"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+func TestFileBackend(t *testing.T) {
+	ctx := context.Background()
+	b, err := NewFileBackend(t.TempDir())
+	require.NoError(t, err)
+
+	_, err = b.Pop(ctx, "list", 0)
+	assert.ErrorIs(t, err, ErrEmpty)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, b.Push(ctx, "list", []byte(fmt.Sprint(i))))
+	}
+	for i := 0; i < 3; i++ {
+		msg, err := b.Pop(ctx, "list", 0)
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprint(i), string(msg))
+	}
+
+	// Concurrent consumers receive every message exactly once.
+	const n = 50
+	for i := 0; i < n; i++ {
+		require.NoError(t, b.Push(ctx, "list", []byte(fmt.Sprint(i))))
+	}
+	var (
+		mu   sync.Mutex
+		seen = make(map[string]int)
+		wg   sync.WaitGroup
+	)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				msg, err := b.Pop(ctx, "list", 0)
+				if err != nil {
+					return
+				}
+				mu.Lock()
+				seen[string(msg)]++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, seen, n)
+	for msg, count := range seen {
+		assert.Equal(t, 1, count, msg)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+	b, err := Open("file://" + dir)
+	require.NoError(t, err)
+	assert.IsType(t, &FileBackend{}, b)
+
+	_, err = Open("ftp://example.com/queue")
+	assert.Error(t, err)
+}
+
+// unitSource enumerates one unit per file and emits one chunk per unit.
+type unitSource struct {
+	sources.CommonSourceUnitUnmarshaller
+	files []string
+}
+
+func (s *unitSource) Enumerate(ctx context.Context, reporter sources.UnitReporter) error {
+	for _, f := range s.files {
+		if err := reporter.UnitOk(ctx, sources.CommonSourceUnit{ID: f}); err != nil {
+			return err
+		}
+	}
+	return reporter.UnitErr(ctx, fmt.Errorf("unable to list one file"))
+}
+
+func (s *unitSource) ChunkUnit(ctx context.Context, unit sources.SourceUnit, reporter sources.ChunkReporter) error {
+	id, _ := unit.SourceUnitID()
+	return reporter.ChunkOk(ctx, sources.Chunk{
+		SourceMetadata: &source_metadatapb.MetaData{
+			Data: &source_metadatapb.MetaData_Filesystem{
+				Filesystem: &source_metadatapb.Filesystem{File: id},
+			},
+		},
+		Data: []byte("secret in " + id),
+	})
+}
+
+func TestDistributedScan(t *testing.T) {
+	ctx := context.Background()
+	backend, err := NewFileBackend(t.TempDir())
+	require.NoError(t, err)
+
+	src := &unitSource{files: []string{"a.txt", "b.txt", "c.txt"}}
+	coord, err := NewCoordinator(backend, "files")
+	require.NoError(t, err)
+	n, err := coord.Enumerate(ctx, src)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		w, err := NewWorker(WorkerConfig{
+			Backend:        backend,
+			Source:         src,
+			SourceName:     "files",
+			RunID:          coord.RunID(),
+			ID:             fmt.Sprintf("worker-%d", i),
+			IdleTimeout:    time.Millisecond,
+			IncludeSecrets: true,
+		})
+		require.NoError(t, err)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Stand in for the engine: every chunk yields one result.
+			chunks := make(chan *sources.Chunk, 10)
+			assert.NoError(t, w.Run(ctx, sources.ChanReporter{Ch: chunks}))
+			close(chunks)
+			for chunk := range chunks {
+				assert.NoError(t, w.Print(ctx, &detectors.ResultWithMetadata{
+					SourceMetadata: chunk.SourceMetadata,
+					Result:         detectors.Result{Raw: chunk.Data},
+				}))
+			}
+			assert.NoError(t, w.Stop(ctx))
+		}()
+	}
+
+	var raws []string
+	err = coord.Collect(ctx, func(rec output.ResultRecord) error {
+		raws = append(raws, rec.Raw)
+		return nil
+	})
+	wg.Wait()
+	assert.ErrorContains(t, err, "unable to list one file")
+	assert.ElementsMatch(t, []string{"secret in a.txt", "secret in b.txt", "secret in c.txt"}, raws)
+}
+
+// runWorkers starts workers one after another until stop is closed, like a
+// pool of processes that are restarted when they exit.
+func runWorkers(ctx context.Context, t *testing.T, backend Backend, src WorkerSource, run string, stop <-chan struct{}) *sync.WaitGroup {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			w, err := NewWorker(WorkerConfig{
+				Backend:           backend,
+				Source:            src,
+				SourceName:        "files",
+				RunID:             run,
+				ID:                fmt.Sprintf("worker-%d", i),
+				IdleTimeout:       20 * time.Millisecond,
+				VisibilityTimeout: 300 * time.Millisecond,
+			})
+			if !assert.NoError(t, err) {
+				return
+			}
+			chunks := make(chan *sources.Chunk, 10)
+			assert.NoError(t, w.Run(ctx, sources.ChanReporter{Ch: chunks}))
+			close(chunks)
+			for chunk := range chunks {
+				assert.NoError(t, w.Print(ctx, &detectors.ResultWithMetadata{
+					SourceMetadata: chunk.SourceMetadata,
+					Result:         detectors.Result{Raw: chunk.Data, Redacted: "redacted"},
+				}))
+			}
+			assert.NoError(t, w.Stop(ctx))
+		}
+	}()
+	return &wg
+}
+
+func TestCollectRedeliversLostUnits(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	backend, err := NewFileBackend(t.TempDir())
+	require.NoError(t, err)
+
+	src := &unitSource{files: []string{"a.txt", "b.txt", "c.txt"}}
+	coord, err := NewCoordinator(backend, "files")
+	require.NoError(t, err)
+	coord.WithVisibilityTimeout(300 * time.Millisecond)
+	_, err = coord.Enumerate(ctx, src)
+	require.NoError(t, err)
+
+	// A worker claims a unit and dies.
+	msg, err := backend.Pop(ctx, runList(unitsList, coord.RunID()), 0)
+	require.NoError(t, err)
+	var claimed task
+	require.NoError(t, json.Unmarshal(msg, &claimed))
+	dead := &Worker{cfg: WorkerConfig{Backend: backend, RunID: coord.RunID(), ID: "dead"}}
+	require.NoError(t, dead.report(ctx, report{Kind: reportStarted}))
+	require.NoError(t, dead.report(ctx, report{Kind: reportClaimed, TaskID: claimed.ID}))
+	// Another dies before it could claim the unit it took.
+	_, err = backend.Pop(ctx, runList(unitsList, coord.RunID()), 0)
+	require.NoError(t, err)
+
+	stop := make(chan struct{})
+	wg := runWorkers(ctx, t, backend, src, coord.RunID(), stop)
+	files := make(map[string]bool)
+	err = coord.Collect(ctx, func(rec output.ResultRecord) error {
+		assert.Empty(t, rec.Raw, "secrets must be redacted by default")
+		assert.Equal(t, "redacted", rec.Redacted)
+		files[fmt.Sprint(rec.SourceMetadata["Filesystem"]["file"])] = true
+		return nil
+	})
+	close(stop)
+	wg.Wait()
+	assert.ErrorContains(t, err, "unable to list one file")
+	assert.Equal(t, map[string]bool{"a.txt": true, "b.txt": true, "c.txt": true}, files)
+}
+
+func TestRunsAreIsolated(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	backend, err := NewFileBackend(t.TempDir())
+	require.NoError(t, err)
+
+	// A previous run that was abandoned, with a report left behind.
+	src := &unitSource{files: []string{"a.txt", "b.txt"}}
+	old, err := NewCoordinator(backend, "files")
+	require.NoError(t, err)
+	_, err = old.Enumerate(ctx, src)
+	require.NoError(t, err)
+	stale := &Worker{cfg: WorkerConfig{Backend: backend, RunID: old.RunID(), ID: "stale"}}
+	require.NoError(t, stale.report(ctx, report{Kind: reportDone, TaskID: old.RunID() + "-files-1"}))
+
+	coord, err := NewCoordinator(backend, "files")
+	require.NoError(t, err)
+	require.NotEqual(t, old.RunID(), coord.RunID())
+	_, err = coord.Enumerate(ctx, src)
+	require.NoError(t, err)
+	// A report of the old run sent to the new one is ignored.
+	misdirected := &Worker{cfg: WorkerConfig{Backend: backend, RunID: coord.RunID(), ID: "stale"}}
+	require.NoError(t, misdirected.report(ctx, report{Kind: reportDone, TaskID: old.RunID() + "-files-1"}))
+
+	stop := make(chan struct{})
+	wg := runWorkers(ctx, t, backend, src, coord.RunID(), stop)
+	results := 0
+	err = coord.Collect(ctx, func(output.ResultRecord) error {
+		results++
+		return nil
+	})
+	close(stop)
+	wg.Wait()
+	assert.ErrorContains(t, err, "unable to list one file")
+	assert.Equal(t, 2, results)
+
+	// The units of the old run were not touched.
+	for i := 0; i < 2; i++ {
+		_, err := backend.Pop(ctx, runList(unitsList, old.RunID()), 0)
+		assert.NoError(t, err)
+	}
+
+	_, err = NewWorker(WorkerConfig{Backend: backend, Source: src, RunID: "../escape"})
+	assert.Error(t, err)
+}
+
+func TestCollectWithoutWorkers(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	backend, err := NewFileBackend(t.TempDir())
+	require.NoError(t, err)
+
+	coord, err := NewCoordinator(backend, "files")
+	require.NoError(t, err)
+	coord.WithVisibilityTimeout(200 * time.Millisecond)
+	_, err = coord.Enumerate(ctx, &unitSource{files: []string{"a.txt"}})
+	require.NoError(t, err)
+
+	err = coord.Collect(ctx, func(output.ResultRecord) error { return nil })
+	assert.ErrorIs(t, err, ErrNoWorkers)
+	assert.NoError(t, ctx.Err(), "Collect must give up before the context expires")
+}
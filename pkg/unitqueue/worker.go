@@ -0,0 +1,237 @@
+package unitqueue
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/output"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+const (
+	defaultIdleTimeout = time.Minute
+	workerPollTimeout  = 5 * time.Second
+)
+
+// WorkerSource is a source whose units can be received from another process.
+type WorkerSource interface {
+	sources.SourceUnitChunker
+	sources.SourceUnitUnmarshaller
+}
+
+// WorkerConfig configures a Worker.
+type WorkerConfig struct {
+	Backend Backend
+	// Source chunks the units. It must be initialized like the source the
+	// coordinator enumerated.
+	Source WorkerSource
+	// SourceName must match the name given to the coordinator.
+	SourceName string
+	// RunID is the RunID of the coordinator whose units the worker scans.
+	RunID string
+	// ID identifies the worker in reports. It defaults to the host name
+	// and process ID.
+	ID string
+	// IdleTimeout is how long Run waits for new units once the queue is
+	// empty. It defaults to one minute.
+	IdleTimeout time.Duration
+	// VisibilityTimeout must match the coordinator's. The worker reports
+	// a heartbeat three times per timeout until Stop is called. It defaults
+	// to five minutes.
+	VisibilityTimeout time.Duration
+	// IncludeSecrets adds the raw secrets to the results sent to the
+	// coordinator. It is off by default so that secrets are not stored in
+	// the queue.
+	IncludeSecrets bool
+}
+
+// Worker pulls units from the queue and chunks them. It is also the printer
+// of the engine processing its chunks, and sends the results back to the
+// coordinator.
+type Worker struct {
+	cfg WorkerConfig
+
+	heartbeatOnce sync.Once
+	heartbeats    sync.WaitGroup
+	stopOnce      sync.Once
+	stop          chan struct{}
+	idle          atomic.Bool
+}
+
+// NewWorker creates a Worker.
+func NewWorker(cfg WorkerConfig) (*Worker, error) {
+	if cfg.Backend == nil || cfg.Source == nil {
+		return nil, errors.New("a backend and a source are required")
+	}
+	if !validRunID.MatchString(cfg.RunID) {
+		return nil, fmt.Errorf("invalid run ID %q", cfg.RunID)
+	}
+	if cfg.IdleTimeout <= 0 {
+		cfg.IdleTimeout = defaultIdleTimeout
+	}
+	if cfg.VisibilityTimeout <= 0 {
+		cfg.VisibilityTimeout = defaultVisibilityTimeout
+	}
+	if cfg.ID == "" {
+		cfg.ID = defaultWorkerID()
+	}
+	return &Worker{cfg: cfg, stop: make(chan struct{})}, nil
+}
+
+func defaultWorkerID() string {
+	host, _ := os.Hostname()
+	suffix := make([]byte, 2)
+	_, _ = rand.Read(suffix)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(suffix))
+}
+
+// Run chunks units from the queue and passes the chunks to reporter until the
+// queue stayed empty for the idle timeout or ctx is cancelled. Once the engine
+// processed all chunks, Stop must be called.
+func (w *Worker) Run(ctx context.Context, reporter sources.ChunkReporter) error {
+	ctx = context.WithValues(ctx, "worker", w.cfg.ID, "run", w.cfg.RunID)
+	if err := w.report(ctx, report{Kind: reportStarted}); err != nil {
+		return err
+	}
+	w.heartbeatOnce.Do(func() {
+		w.heartbeats.Add(1)
+		go w.heartbeat(ctx)
+	})
+
+	pollTimeout := min(workerPollTimeout, w.cfg.IdleTimeout)
+	idleSince := time.Now()
+	for {
+		msg, err := w.cfg.Backend.Pop(ctx, runList(unitsList, w.cfg.RunID), pollTimeout)
+		if errors.Is(err, ErrEmpty) {
+			if time.Since(idleSince) >= w.cfg.IdleTimeout {
+				ctx.Logger().V(1).Info("no more units, stopping")
+				w.idle.Store(true)
+				return nil
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read units: %w", err)
+		}
+
+		var t task
+		if err := json.Unmarshal(msg, &t); err != nil {
+			ctx.Logger().Error(err, "skipping malformed unit")
+			continue
+		}
+		if err := w.report(ctx, report{Kind: reportClaimed, TaskID: t.ID}); err != nil {
+			return err
+		}
+		done := w.chunkTask(ctx, t, reporter)
+		if err := w.report(ctx, done); err != nil {
+			return err
+		}
+		idleSince = time.Now()
+	}
+}
+
+// chunkTask chunks the unit of t and returns the report for it.
+func (w *Worker) chunkTask(ctx context.Context, t task, reporter sources.ChunkReporter) report {
+	done := report{Kind: reportDone, TaskID: t.ID}
+	if t.Source != w.cfg.SourceName {
+		done.Errors = []string{fmt.Sprintf("unit of source %q sent to a worker of source %q", t.Source, w.cfg.SourceName)}
+		return done
+	}
+	unit, err := w.cfg.Source.UnmarshalSourceUnit(t.Unit)
+	if err != nil {
+		done.Errors = []string{fmt.Sprintf("could not unmarshal unit: %v", err)}
+		return done
+	}
+
+	ctx = context.WithValues(ctx, "unit", unit.Display())
+	ctx.Logger().V(2).Info("chunking unit")
+	counter := &countingReporter{next: reporter}
+	if err := w.cfg.Source.ChunkUnit(ctx, unit, counter); err != nil {
+		counter.errs = append(counter.errs, err.Error())
+	}
+	done.Chunks = counter.chunks
+	done.Errors = counter.errs
+	return done
+}
+
+// heartbeat tells the coordinator that the worker is alive until Stop is
+// called or ctx is cancelled.
+func (w *Worker) heartbeat(ctx context.Context) {
+	defer w.heartbeats.Done()
+	ticker := time.NewTicker(w.cfg.VisibilityTimeout / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			if err := w.report(ctx, report{Kind: reportHeartbeat}); err != nil {
+				ctx.Logger().Error(err, "unable to send heartbeat")
+			}
+		}
+	}
+}
+
+// Print implements the engine's printer by sending the result back to the
+// coordinator. Raw secrets are left out unless IncludeSecrets is set.
+func (w *Worker) Print(ctx context.Context, r *detectors.ResultWithMetadata) error {
+	rec, err := output.NewResultRecord(r, output.RecordOptions{IncludeSecrets: w.cfg.IncludeSecrets})
+	if err != nil {
+		return fmt.Errorf("could not marshal result: %w", err)
+	}
+	return w.report(ctx, report{Kind: reportResult, Result: &rec})
+}
+
+// Stop tells the coordinator that the worker will not send any more results.
+func (w *Worker) Stop(ctx context.Context) error {
+	var err error
+	w.stopOnce.Do(func() {
+		close(w.stop)
+		// A heartbeat sent after the stop report would make the coordinator
+		// count the worker as running again.
+		w.heartbeats.Wait()
+		err = w.report(ctx, report{Kind: reportStopped, Idle: w.idle.Load()})
+	})
+	return err
+}
+
+func (w *Worker) report(ctx context.Context, r report) error {
+	r.Worker = w.cfg.ID
+	msg, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if err := w.cfg.Backend.Push(ctx, runList(reportsList, w.cfg.RunID), msg); err != nil {
+		return fmt.Errorf("unable to send report: %w", err)
+	}
+	return nil
+}
+
+// countingReporter forwards chunks and records what the coordinator needs to
+// know about the unit.
+type countingReporter struct {
+	next   sources.ChunkReporter
+	chunks uint64
+	errs   []string
+}
+
+func (r *countingReporter) ChunkOk(ctx context.Context, chunk sources.Chunk) error {
+	r.chunks++
+	return r.next.ChunkOk(ctx, chunk)
+}
+
+func (r *countingReporter) ChunkErr(ctx context.Context, err error) error {
+	r.errs = append(r.errs, err.Error())
+	return r.next.ChunkErr(ctx, err)
+}